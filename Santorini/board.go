@@ -0,0 +1,55 @@
+package Santorini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// boardTile is how one square is drawn: its height, the worker on it, and
+// its number, inside a box of carets and bars.
+var boardTile = [...]string{
+	" ^^^^ ",
+	" |%c | ",
+	" | %c| ",
+	" %2d   ",
+	" ^^^^ ",
+	"      ",
+}
+
+// DrawBoard draws p as text, one six by six tile per square, with rows of
+// the board one above the other. Each tile shows the square's height, 0 to
+// 4, the letter of any worker on it, and the square's number.
+func DrawBoard(p Position) string {
+	var sb strings.Builder
+	for row := 0; row < 5; row++ {
+		for line := range boardTile {
+			for col := 0; col < 5; col++ {
+				sq := row*5 + col
+				switch line {
+				case 1:
+					fmt.Fprintf(&sb, boardTile[line], '0'+rune(heightAt(p, occupancy[sq])))
+				case 2:
+					fmt.Fprintf(&sb, boardTile[line], workerAt(p, occupancy[sq]))
+				case 3:
+					fmt.Fprintf(&sb, boardTile[line], sq)
+				default:
+					sb.WriteString(boardTile[line])
+				}
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// workerAt is the letter of the worker on bit, or a space if there isn't one.
+func workerAt(p Position, bit int32) rune {
+	for _, ply := range []bool{false, true} {
+		for _, second := range []bool{false, true} {
+			if p.worker(ply, second)&bit != 0 {
+				return rune(workerLetter(ply, second)[0])
+			}
+		}
+	}
+	return ' '
+}
@@ -0,0 +1,40 @@
+package Santorini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDrawBoard(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	lines := strings.Split(strings.TrimSuffix(DrawBoard(position), "\n"), "\n")
+	if len(lines) != 30 {
+		t.Fatalf("expected 30 lines, got %v", len(lines))
+	}
+	for i, line := range lines {
+		if len(line) != 30 {
+			t.Fatalf("expected line %v to be 30 wide, got %q", i, line)
+		}
+	}
+	// Square 1 has a dome, and Black's first worker is on square 0.
+	want := []string{
+		" ^^^^  ^^^^  ^^^^  ^^^^  ^^^^ ",
+		" |0 |  |4 |  |0 |  |0 |  |3 | ",
+		" | X|  |  |  |  |  |  |  |  | ",
+		"  0     1     2     3     4   ",
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Fatalf("expected line %v to be %q, got %q", i, line, lines[i])
+		}
+	}
+	if got := lines[8]; got != " | A|  |  |  |  |  | B|  |  | " {
+		t.Fatalf("expected White's workers on squares 5 and 8, got %q", got)
+	}
+	if got := lines[21]; got != " 15    16    17    18    19   " {
+		t.Fatalf("expected square numbers, got %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package Santorini
+
+// Evaluator gives a static score to a position that isn't decided yet, from
+// the point of view of the side to move: positive when they are better off.
+// Scores need to stay far below WinScore, so a real win always counts for
+// more than a good position.
+type Evaluator interface {
+	Evaluate(p Position) int
+}
+
+// EvaluatorFunc lets an ordinary function be used as an Evaluator.
+type EvaluatorFunc func(p Position) int
+
+func (f EvaluatorFunc) Evaluate(p Position) int {
+	return f(p)
+}
+
+// Weighted is one term of a Combination.
+type Weighted struct {
+	Weight    int
+	Evaluator Evaluator
+}
+
+// Combination adds up the weighted scores of several evaluators.
+type Combination []Weighted
+
+func (c Combination) Evaluate(p Position) int {
+	score := 0
+	for _, w := range c {
+		score += w.Weight * w.Evaluator.Evaluate(p)
+	}
+	return score
+}
+
+// DefaultEvaluator is what a Searcher uses unless told otherwise. Standing
+// high matters most, then having somewhere to climb to.
+var DefaultEvaluator Evaluator = Combination{
+	{100, WorkerHeight{}},
+	{20, HeightAdjacency{}},
+	{5, Mobility{}},
+	{10, CenterControl{}},
+	{2, WorkerDistance{}},
+}
+
+// sideWorkers returns the workers of the side to move and of their opponent.
+// Workers that aren't on the board yet are left out.
+func sideWorkers(p Position) (mine, theirs []int32) {
+	white := []int32{p.A, p.B}
+	black := []int32{p.X, p.Y}
+	if p.Ply {
+		white, black = black, white
+	}
+	placed := func(ws []int32) []int32 {
+		var ret []int32
+		for _, w := range ws {
+			if w != 0 {
+				ret = append(ret, w)
+			}
+		}
+		return ret
+	}
+	return placed(white), placed(black)
+}
+
+// difference scores each side's workers with f and subtracts the opponent's
+// total from the side to move's.
+func difference(p Position, f func(p Position, worker int32, opponents []int32) int) int {
+	mine, theirs := sideWorkers(p)
+	score := 0
+	for _, w := range mine {
+		score += f(p, w, theirs)
+	}
+	for _, w := range theirs {
+		score -= f(p, w, mine)
+	}
+	return score
+}
+
+// WorkerHeight counts the levels under each side's workers. Workers need to
+// get up high to win.
+type WorkerHeight struct{}
+
+func (WorkerHeight) Evaluate(p Position) int {
+	return difference(p, func(p Position, w int32, _ []int32) int {
+		return heightAt(p, w)
+	})
+}
+
+// HeightAdjacency counts the free squares next to each worker that are one
+// level above it, each weighted by its level: the climbs open to the worker
+// on its next move.
+type HeightAdjacency struct{}
+
+func (HeightAdjacency) Evaluate(p Position) int {
+	return difference(p, func(p Position, w int32, _ []int32) int {
+		up := heightAt(p, w) + 1
+		score := 0
+		for _, sq := range maskBits(moveTargets(p, w)) {
+			if h := heightAt(p, sq); h == up {
+				score += h
+			}
+		}
+		return score
+	})
+}
+
+// Mobility counts the squares each worker can move to, the same ones
+// legalMoves2 lists. A side with few moves is close to being shut in.
+type Mobility struct{}
+
+func (Mobility) Evaluate(p Position) int {
+	return difference(p, func(p Position, w int32, _ []int32) int {
+		n := 0
+		for m := moveTargets(p, w); m != 0; m &= m - 1 {
+			n++
+		}
+		return n
+	})
+}
+
+// chebyshev is the number of king moves between two squares.
+func chebyshev(a, b int) int {
+	dr, dc := a/5-b/5, a%5-b%5
+	if dr < 0 {
+		dr = -dr
+	}
+	if dc < 0 {
+		dc = -dc
+	}
+	if dr > dc {
+		return dr
+	}
+	return dc
+}
+
+// CenterControl scores 2 for a worker on the center square, 1 on the ring
+// around it and 0 on the edge. Central workers reach more of the board.
+type CenterControl struct{}
+
+func (CenterControl) Evaluate(p Position) int {
+	return difference(p, func(p Position, w int32, _ []int32) int {
+		return 2 - chebyshev(square(w), 12)
+	})
+}
+
+// WorkerDistance rewards staying close to the opponent's workers, where a
+// worker can block their climbs by building domes. It scores each worker
+// minus its distance in king moves to the nearest opposing worker.
+type WorkerDistance struct{}
+
+func (WorkerDistance) Evaluate(p Position) int {
+	return difference(p, func(p Position, w int32, opponents []int32) int {
+		if len(opponents) == 0 {
+			return 0
+		}
+		nearest := 4
+		for _, o := range opponents {
+			if d := chebyshev(square(w), square(o)); d < nearest {
+				nearest = d
+			}
+		}
+		return -nearest
+	})
+}
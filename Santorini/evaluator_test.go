@@ -0,0 +1,70 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+func TestEvaluators(t *testing.T) {
+	// White stands on 6 (level 1) and 7 (level 2), Black on 12 and 16, both
+	// on the ground next to a level 1 tile on 11.
+	white, e := NewPosition("|0000001200010200000000000|06071216|w|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	black := white
+	black.Ply = true
+
+	tests := []struct {
+		name      string
+		evaluator Evaluator
+		want      int // For White to move.
+	}{
+		{"WorkerHeight", WorkerHeight{}, 3},
+		{"HeightAdjacency", HeightAdjacency{}, -2},
+		{"Mobility", Mobility{}, 1},
+		{"CenterControl", CenterControl{}, -1},
+		{"WorkerDistance", WorkerDistance{}, 1},
+		{"Combination", Combination{{10, WorkerHeight{}}, {1, Mobility{}}}, 31},
+		{"EvaluatorFunc", EvaluatorFunc(func(p Position) int { return 7 }), 7},
+	}
+	for _, tc := range tests {
+		if got := tc.evaluator.Evaluate(white); got != tc.want {
+			t.Fatalf("%v: expected %v for White, got %v", tc.name, tc.want, got)
+		}
+		if _, ok := tc.evaluator.(EvaluatorFunc); ok {
+			continue
+		}
+		// Scores are for the side to move, so they flip with it.
+		if got := tc.evaluator.Evaluate(black); got != -tc.want {
+			t.Fatalf("%v: expected %v for Black, got %v", tc.name, -tc.want, got)
+		}
+	}
+}
+
+func TestEvaluatorsWhilePlacing(t *testing.T) {
+	position := UpdatePosition(NewGame(), MoveBuild{occupancy[7] | occupancy[12], 0, false, false})
+	// Black hasn't placed yet, which must not upset any of the evaluators.
+	if got := DefaultEvaluator.Evaluate(position); got >= 0 {
+		t.Fatalf("expected Black to be behind White's placed workers, got %v", got)
+	}
+}
+
+func TestSearchWithEvaluator(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	calls := 0
+	s := NewSearcher()
+	s.Evaluator = EvaluatorFunc(func(p Position) int {
+		calls++
+		return 0
+	})
+	got := s.Search(position, 2)
+	if calls == 0 {
+		t.Fatalf("the search never called the evaluator")
+	}
+	if got.Score != 0 {
+		t.Fatalf("expected every line to score 0, got %v", got.Score)
+	}
+}
@@ -0,0 +1,144 @@
+package Santorini
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// MaxDepth is as deep as IterativeSearch will go.
+const MaxDepth = 64
+
+// Limits bounds an IterativeSearch. Zero fields don't limit anything.
+type Limits struct {
+	Depth    int           // Deepest iteration to run.
+	Nodes    int           // Give up after about this many nodes.
+	MoveTime time.Duration // Give up after about this long.
+}
+
+// Info describes one completed iteration of IterativeSearch.
+type Info struct {
+	Depth   int           // Plies searched.
+	Score   int           // Score of the best move.
+	Nodes   int           // Nodes searched so far, all iterations together.
+	Elapsed time.Duration // Time since the search started.
+	NPS     int           // Nodes per second so far.
+	PV      []MoveBuild   // Principal variation.
+}
+
+// budget is how much an IterativeSearch may spend.
+type budget struct {
+	ctx      context.Context
+	deadline time.Time // Zero for no deadline.
+	nodes    int       // Zero for no node limit.
+	shared   *int64    // Nodes of a parallel search, nil on one thread.
+}
+
+// outOfBudget reports whether the search should give up. The clock and the
+// context are slow to look at, so unless always is set they are only checked
+// every 1024 nodes.
+func (s *Searcher) outOfBudget(always bool) bool {
+	if s.aborted {
+		return true
+	}
+	if s.budget.nodes > 0 && s.totalNodes() >= s.budget.nodes {
+		s.aborted = true
+	}
+	if always || s.nodes&1023 == 0 {
+		if s.budget.ctx.Err() != nil {
+			s.aborted = true
+		}
+		if !s.budget.deadline.IsZero() && time.Now().After(s.budget.deadline) {
+			s.aborted = true
+		}
+	}
+	return s.aborted
+}
+
+// totalNodes is the number of nodes searched so far, by every thread of a
+// parallel search. Threads add their nodes to the shared count 1024 at a
+// time, so it only sees the others' nodes to within that.
+func (s *Searcher) totalNodes() int {
+	if s.budget.shared == nil {
+		return s.nodes
+	}
+	if s.nodes-s.published >= 1024 {
+		atomic.AddInt64(s.budget.shared, int64(s.nodes-s.published))
+		s.published = s.nodes
+	}
+	return int(atomic.LoadInt64(s.budget.shared)) + s.nodes - s.published
+}
+
+// IterativeSearch searches p one ply deeper at a time until it runs out of
+// budget, ctx is cancelled, a win or loss is proven, or it completes
+// limits.Depth plies. It returns the result of the deepest iteration that
+// finished, and report, if not nil, hears about each one as it does.
+//
+// The first iteration always runs to the end, so there is a move to return
+// even when the budget is tiny.
+func (s *Searcher) IterativeSearch(ctx context.Context, p Position, limits Limits, report func(Info)) SearchResult {
+	start := time.Now()
+	s.nodes, s.published = 0, 0
+	s.stats = SearchStats{}
+	s.limited, s.aborted = false, false
+	s.budget = budget{ctx: ctx, nodes: limits.Nodes}
+	if limits.MoveTime > 0 {
+		s.budget.deadline = start.Add(limits.MoveTime)
+	}
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > MaxDepth {
+		maxDepth = MaxDepth
+	}
+	// Positions built by hand have no hash, and the table needs one.
+	p.Hash = p.ComputeHash()
+	helpers := s.startHelpers(ctx, p)
+
+	var best SearchResult
+	for depth := 1; depth <= maxDepth; depth++ {
+		r := s.searchRoot(p, depth)
+		if s.aborted {
+			break
+		}
+		best = r
+		// From here on, running out of budget stops the search.
+		s.limited = true
+		if s.Tracer != nil {
+			stats := s.stats
+			stats.Nodes, stats.Elapsed = s.totalNodes(), time.Since(start)
+			s.Tracer.Progress(stats)
+		}
+
+		if report != nil {
+			elapsed := time.Since(start)
+			nodes := s.totalNodes()
+			report(Info{
+				Depth:   depth,
+				Score:   r.Score,
+				Nodes:   nodes,
+				Elapsed: elapsed,
+				NPS:     nps(nodes, elapsed),
+				PV:      r.PV,
+			})
+		}
+		// Nothing to choose between, or the game is decided.
+		if len(r.PV) == 0 || r.Score > WinScore-MaxDepth || r.Score < -WinScore+MaxDepth {
+			break
+		}
+		if s.outOfBudget(true) {
+			break
+		}
+	}
+	helped := helpers.stop()
+	best.Nodes = s.nodes + helped.Nodes
+	best.Stats = s.finish(helped, start)
+	return best
+}
+
+// nps is the rate of nodes searched in elapsed. A first iteration can end
+// within one tick of a coarse clock, and then there is no rate to give.
+func nps(nodes int, elapsed time.Duration) int {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int(int64(nodes) * int64(time.Second) / int64(elapsed))
+}
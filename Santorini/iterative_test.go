@@ -0,0 +1,99 @@
+package Santorini
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIterativeSearchDepth(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	var depths []int
+	got := NewSearcher().IterativeSearch(context.Background(), position, Limits{Depth: 3}, func(info Info) {
+		depths = append(depths, info.Depth)
+		if len(info.PV) == 0 || info.Nodes == 0 {
+			t.Fatalf("iteration %v reported no line or no nodes: %+v", info.Depth, info)
+		}
+	})
+	if len(depths) != 3 || depths[0] != 1 || depths[2] != 3 {
+		t.Fatalf("expected iterations 1 to 3, got %v", depths)
+	}
+	if got.Depth != 3 {
+		t.Fatalf("expected depth 3, got %v", got.Depth)
+	}
+	// Searching the earlier iterations first only changes the move order, so
+	// the score has to match a plain search to the same depth.
+	if want := Search(position, 3); got.Score != want.Score {
+		t.Fatalf("expected score %v, got %v", want.Score, got.Score)
+	}
+}
+
+func TestIterativeSearchStopsOnWin(t *testing.T) {
+	position, e := NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := NewSearcher().IterativeSearch(context.Background(), position, Limits{}, nil)
+	if got.Depth != 1 || got.Score != WinScore-1 || got.Move.Move != occupancy[12] {
+		t.Fatalf("expected the climb to 12 after one ply, got %+v", got)
+	}
+}
+
+func TestIterativeSearchBudgets(t *testing.T) {
+	position, e := NewPosition("|0000000000000000000000000|07121117|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	legal := make(map[MoveBuild]bool)
+	for _, mb := range LegalBuildMoves(position) {
+		legal[mb] = true
+	}
+
+	// A cancelled context still gets the first iteration's move.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got := NewSearcher().IterativeSearch(ctx, position, Limits{}, nil)
+	if got.Depth != 1 || !legal[got.Move] {
+		t.Fatalf("expected a legal move from the first iteration, got %+v", got)
+	}
+
+	got = NewSearcher().IterativeSearch(context.Background(), position, Limits{Nodes: 5000}, nil)
+	if !legal[got.Move] {
+		t.Fatalf("expected a legal move, got %+v", got.Move)
+	}
+	// The node limit applies after the first iteration, and is checked at
+	// every node.
+	if got.Nodes > 5000+len(legal)+1 {
+		t.Fatalf("node budget of 5000 overrun: %v", got.Nodes)
+	}
+
+	start := time.Now()
+	got = NewSearcher().IterativeSearch(context.Background(), position, Limits{MoveTime: 50 * time.Millisecond}, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("50ms search took %v", elapsed)
+	}
+	if !legal[got.Move] || got.Depth < 1 {
+		t.Fatalf("expected a legal move, got %+v", got)
+	}
+}
+
+func TestNPS(t *testing.T) {
+	for _, tc := range []struct {
+		nodes   int
+		elapsed time.Duration
+		want    int
+	}{
+		{1000, 0, 0},
+		{1000, -time.Millisecond, 0},
+		{1000, time.Second, 1000},
+		{1500, 500 * time.Millisecond, 3000},
+		{1, time.Nanosecond, 1000000000},
+	} {
+		if got := nps(tc.nodes, tc.elapsed); got != tc.want {
+			t.Fatalf("%v nodes in %v: expected %v nps, got %v", tc.nodes, tc.elapsed, tc.want, got)
+		}
+	}
+}
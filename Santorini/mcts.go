@@ -0,0 +1,242 @@
+package Santorini
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// DefaultExploration is the textbook UCT exploration constant, the square
+// root of 2.
+const DefaultExploration = math.Sqrt2
+
+// Rollout picks the node a playout moves on to from children, the children
+// of n. It is only called when there is at least one child.
+type Rollout func(n GameNode, children []GameNode, r *rand.Rand) GameNode
+
+// RandomRollout plays uniformly random moves.
+func RandomRollout(_ GameNode, children []GameNode, r *rand.Rand) GameNode {
+	return children[r.Intn(len(children))]
+}
+
+// GreedyRollout plays the child e likes best for the side that moves into it,
+// except that with probability epsilon it plays a random move instead, so
+// playouts don't all follow the same line. Children that aren't Positions
+// can't be evaluated, so they get a random move.
+func GreedyRollout(e Evaluator, epsilon float64) Rollout {
+	return func(n GameNode, children []GameNode, r *rand.Rand) GameNode {
+		if r.Float64() < epsilon {
+			return RandomRollout(n, children, r)
+		}
+		var best GameNode
+		bestScore := 0
+		for _, c := range children {
+			p, ok := c.(Position)
+			if !ok {
+				return RandomRollout(n, children, r)
+			}
+			// The child is scored for its side to move, the opponent.
+			if score := -e.Evaluate(p); best == nil || score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+		return best
+	}
+}
+
+// MCTS is a Monte Carlo tree search over any GameNode. It grows a tree from
+// the root one node per iteration, picking which branch to grow with UCT,
+// and scores each new node by playing the game out to the end.
+//
+// It never looks at Score, only at Children and Outcome, so it copes with
+// Santorini's branching factor much better than a fixed depth search does.
+type MCTS struct {
+	// Iterations is the number of playouts to run.
+	Iterations int
+	// Exploration weighs trying rarely visited moves against replaying
+	// moves that have done well so far.
+	Exploration float64
+	// Rollout chooses moves during playouts. Nil means RandomRollout.
+	Rollout Rollout
+	// MaxRolloutDepth cuts off playouts that go on too long, counting them
+	// as half a win for each side. Zero means no limit.
+	MaxRolloutDepth int
+	// Rand drives every random choice. Seed it for reproducible searches.
+	Rand *rand.Rand
+	// Tracer, if not nil, hears about each node added to the tree, and gets
+	// the stats every 1024 iterations.
+	Tracer Tracer
+
+	stats SearchStats
+}
+
+// NewMCTS returns an MCTS running iterations playouts with random rollouts,
+// the default exploration constant, and randomness seeded from seed.
+func NewMCTS(iterations int, seed int64) *MCTS {
+	return &MCTS{
+		Iterations:  iterations,
+		Exploration: DefaultExploration,
+		Rand:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+// MCTSMove is what the search learned about one child of the root.
+type MCTSMove struct {
+	Node   GameNode
+	Visits int
+	// Wins counts the playouts through Node won by the side to move at the
+	// root, with half a win for each playout that was cut off.
+	Wins float64
+}
+
+// MCTSResult is the outcome of an MCTS search.
+type MCTSResult struct {
+	// Best is the most visited child of the root, or nil when the root is
+	// already decided and there is nothing to play.
+	Best GameNode
+	// Value is the fraction of playouts through Best won by the side to
+	// move at the root.
+	Value float64
+	// Moves lists every child the search tried, most visited first.
+	Moves []MCTSMove
+	// Iterations is the number of playouts run.
+	Iterations int
+	// Stats counts the nodes added to the tree and played through in
+	// playouts. Depth is the depth of the tree.
+	Stats SearchStats
+}
+
+// mctsNode is one node of the search tree.
+type mctsNode struct {
+	state    GameNode
+	parent   *mctsNode
+	ply      int
+	children []*mctsNode
+	// untried holds the children of state that have no node yet.
+	untried []GameNode
+	outcome rune
+	visits  int
+	// wins is counted for the side that moved into state.
+	wins float64
+}
+
+func (m *MCTS) newNode(state GameNode, parent *mctsNode) *mctsNode {
+	n := &mctsNode{state: state, parent: parent, outcome: state.Outcome()}
+	if parent != nil {
+		n.ply = parent.ply + 1
+	}
+	m.stats.Nodes++
+	m.stats.reach(n.ply)
+	if m.Tracer != nil {
+		m.Tracer.Visit(state, n.ply)
+	}
+	if n.outcome == '?' {
+		n.untried = state.Children()
+		m.stats.Expanded++
+		m.stats.Children += len(n.untried)
+	}
+	return n
+}
+
+// uct picks the child of n with the best upper confidence bound.
+func (m *MCTS) uct(n *mctsNode) *mctsNode {
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, c := range n.children {
+		v := c.wins/float64(c.visits) + m.Exploration*math.Sqrt(logVisits/float64(c.visits))
+		if v > bestValue {
+			best, bestValue = c, v
+		}
+	}
+	return best
+}
+
+// playout plays the game out from n, whose outcome is already known, and
+// returns 'W' or 'B' for the winner, or 0 for a playout that was cut off.
+func (m *MCTS) playout(n GameNode, outcome rune) rune {
+	rollout := m.Rollout
+	if rollout == nil {
+		rollout = RandomRollout
+	}
+	for depth := 0; outcome == '?'; depth++ {
+		if m.MaxRolloutDepth > 0 && depth >= m.MaxRolloutDepth {
+			return 0
+		}
+		children := n.Children()
+		if len(children) == 0 {
+			// Undecided with nowhere to go: the side to move is stuck.
+			return mover(n)
+		}
+		n = rollout(n, children, m.Rand)
+		outcome = n.Outcome()
+		m.stats.Nodes++
+	}
+	return outcome
+}
+
+// mover is the side that moved into n, as 'W' or 'B'.
+func mover(n GameNode) rune {
+	if n.WhichPly() {
+		return 'W'
+	}
+	return 'B'
+}
+
+// Search runs the playouts from root and reports the most visited move.
+func (m *MCTS) Search(root GameNode) MCTSResult {
+	start := time.Now()
+	m.stats = SearchStats{}
+	tree := m.newNode(root, nil)
+	for i := 0; i < m.Iterations; i++ {
+		if m.Tracer != nil && i > 0 && i%1024 == 0 {
+			m.stats.Elapsed = time.Since(start)
+			m.Tracer.Progress(m.stats)
+		}
+		n := tree
+		// Selection: walk down through fully expanded nodes.
+		for len(n.untried) == 0 && len(n.children) > 0 {
+			n = m.uct(n)
+		}
+		// Expansion: add one untried child.
+		if k := len(n.untried); k > 0 {
+			j := m.Rand.Intn(k)
+			state := n.untried[j]
+			n.untried[j] = n.untried[k-1]
+			n.untried = n.untried[:k-1]
+			c := m.newNode(state, n)
+			n.children = append(n.children, c)
+			n = c
+		}
+		// Simulation and backpropagation.
+		winner := m.playout(n.state, n.outcome)
+		for ; n != nil; n = n.parent {
+			n.visits++
+			switch winner {
+			case mover(n.state):
+				n.wins++
+			case 0:
+				n.wins += 0.5
+			}
+		}
+	}
+
+	m.stats.Elapsed = time.Since(start)
+	if m.Tracer != nil {
+		m.Tracer.Progress(m.stats)
+	}
+	result := MCTSResult{Iterations: m.Iterations, Stats: m.stats}
+	for _, c := range tree.children {
+		result.Moves = append(result.Moves, MCTSMove{c.state, c.visits, c.wins})
+	}
+	sort.SliceStable(result.Moves, func(i, j int) bool {
+		return result.Moves[i].Visits > result.Moves[j].Visits
+	})
+	if len(result.Moves) > 0 {
+		best := result.Moves[0]
+		result.Best = best.Node
+		result.Value = best.Wins / float64(best.Visits)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package Santorini
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// mockTree gives White three moves. After "safe" every reply loses for
+// Black, after "trap" every reply wins for Black, and after "mixed" Black
+// has one of each.
+func mockTree() MockGameNode {
+	leaf := func(name string, winner rune) MockGameNode {
+		return MockGameNode{Name: name, Ply: false, Result: winner}
+	}
+	return MockGameNode{Name: "root", Result: '?', Kids: []MockGameNode{
+		{Name: "trap", Ply: true, Result: '?', Kids: []MockGameNode{
+			leaf("trap-1", 'B'), leaf("trap-2", 'B'),
+		}},
+		{Name: "mixed", Ply: true, Result: '?', Kids: []MockGameNode{
+			leaf("mixed-1", 'W'), leaf("mixed-2", 'B'),
+		}},
+		{Name: "safe", Ply: true, Result: '?', Kids: []MockGameNode{
+			leaf("safe-1", 'W'), leaf("safe-2", 'W'),
+		}},
+	}}
+}
+
+func TestMCTSMockTree(t *testing.T) {
+	got := NewMCTS(500, 1).Search(mockTree())
+	if got.Best == nil || got.Best.String() != "safe" {
+		t.Fatalf("expected safe, got %v", got.Moves)
+	}
+	if got.Value != 1 {
+		t.Fatalf("expected every playout through safe to win, got %v", got.Value)
+	}
+	if len(got.Moves) != 3 || got.Moves[2].Node.String() != "trap" {
+		t.Fatalf("expected trap to be the least visited move, got %v", got.Moves)
+	}
+}
+
+func TestMCTSReproducible(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	a := NewMCTS(300, 7).Search(position)
+	b := NewMCTS(300, 7).Search(position)
+	if len(a.Moves) != len(b.Moves) {
+		t.Fatalf("same seed tried %v and %v moves", len(a.Moves), len(b.Moves))
+	}
+	for i := range a.Moves {
+		if a.Moves[i].Node.String() != b.Moves[i].Node.String() || a.Moves[i].Visits != b.Moves[i].Visits {
+			t.Fatalf("same seed gave different searches: %v and %v", a.Moves[i], b.Moves[i])
+		}
+	}
+}
+
+func TestMCTSBlocksClimb(t *testing.T) {
+	// Black's worker on 22 can climb onto 23 next turn unless White's worker
+	// on 18 steps next to it and domes it.
+	position, e := NewPosition("|0000000000000000000000230|00180422|w|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	for name, m := range map[string]*MCTS{
+		"random": NewMCTS(2000, 3),
+		"greedy": {Iterations: 2000, Exploration: DefaultExploration,
+			Rollout: GreedyRollout(DefaultEvaluator, 0.2), Rand: rand.New(rand.NewSource(3))},
+	} {
+		got := m.Search(position)
+		best, ok := got.Best.(Position)
+		if !ok || best.B4&occupancy[23] == 0 {
+			t.Fatalf("%v: expected a dome on 23, got %v", name, got.Best)
+		}
+	}
+}
+
+func TestMCTSDecidedRoot(t *testing.T) {
+	// Black can climb onto 12, so the game is already over.
+	position, e := NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := NewMCTS(10, 1).Search(position)
+	if got.Best != nil || len(got.Moves) != 0 {
+		t.Fatalf("expected nothing to play, got %v", got.Moves)
+	}
+}
@@ -0,0 +1,99 @@
+package Santorini
+
+import (
+	"math/bits"
+)
+
+// boardMask has a bit set for each of the 25 squares.
+const boardMask uint32 = 1<<25 - 1
+
+// adjacent[sq] has a bit set for each square a king's move away from sq.
+var adjacent [25]uint32
+
+func init() {
+	for sq := 0; sq < 25; sq++ {
+		r, c := sq/5, sq%5
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				nr, nc := r+dr, c+dc
+				if (dr == 0 && dc == 0) || nr < 0 || nr > 4 || nc < 0 || nc > 4 {
+					continue
+				}
+				adjacent[sq] |= 1 << uint(nr*5+nc)
+			}
+		}
+	}
+}
+
+// maskBits splits a bitboard into its single bit occupancy masks.
+func maskBits(m uint32) []int32 {
+	var ret []int32
+	for ; m != 0; m &= m - 1 {
+		ret = append(ret, occupancy[bits.TrailingZeros32(m)])
+	}
+	return ret
+}
+
+// moveTargets is the set of squares the worker on piece can move to: next
+// to it, not taken or domed, and no more than one level up.
+func moveTargets(p Position, piece int32) uint32 {
+	mask := adjacent[square(piece)] &^ uint32(p.A|p.B|p.X|p.Y|p.B4)
+	// If you're not at least 1 high, can't go to 2 or 3
+	if piece&p.B1 == 0 {
+		mask &^= uint32(p.B2 | p.B3)
+	}
+	// If you're not at least 2 high, can't go to 3
+	if piece&p.B2 == 0 {
+		mask &^= uint32(p.B3)
+	}
+	return mask
+}
+
+// AppendMoves appends every move and build open to the side to move to buf,
+// and returns the extended buffer. Moves come worker by worker, destination
+// square by destination square, then build square by build square. A move
+// with nowhere to build afterwards isn't legal, so it is left out.
+//
+// It doesn't allocate when buf has room, so callers that generate moves over
+// and over should hang on to their buffer and pass it back in as buf[:0].
+func AppendMoves(buf []MoveBuild, p Position) []MoveBuild {
+	if p.Placing {
+		return appendPlacements(buf, p)
+	}
+	workers := [2]int32{p.A, p.B}
+	if p.Ply {
+		workers = [2]int32{p.X, p.Y}
+	}
+	occupied := uint32(p.A | p.B | p.X | p.Y)
+	for i, from := range workers {
+		// The worker's old square is free to build on once it has moved.
+		blocked := (occupied | uint32(p.B4)) &^ uint32(from)
+		for moves := moveTargets(p, from); moves != 0; moves &= moves - 1 {
+			to := bits.TrailingZeros32(moves)
+			for builds := adjacent[to] &^ blocked; builds != 0; builds &= builds - 1 {
+				buf = append(buf, MoveBuild{
+					Move:  occupancy[to],
+					Build: occupancy[bits.TrailingZeros32(builds)],
+					Ply:   p.Ply,
+					Piece: i == 1,
+				})
+			}
+		}
+	}
+	return buf
+}
+
+// appendPlacements appends every pair of squares the side to move could put
+// its workers on: anywhere that isn't taken or domed. Both workers go down
+// in one move, so turns keep alternating as they do in the rest of the game.
+func appendPlacements(buf []MoveBuild, p Position) []MoveBuild {
+	free := boardMask &^ uint32(p.A|p.B|p.X|p.Y|p.B4)
+	for first := free; first != 0; first &= first - 1 {
+		i := bits.TrailingZeros32(first)
+		for second := first & (first - 1); second != 0; second &= second - 1 {
+			j := bits.TrailingZeros32(second)
+			buf = append(buf, MoveBuild{occupancy[i] | occupancy[j], 0, p.Ply, false})
+		}
+	}
+	return buf
+}
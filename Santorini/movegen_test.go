@@ -0,0 +1,216 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+// The map based move generator the bitmask one replaced, kept to check
+// against and to benchmark.
+//
+// map where keys are positionally encoded pieces
+// like 0b0000001000000000 for a piece in square 6
+// and values are list of positially encoded possible
+// king moves if it were a chessboard.
+var legacyKingMoves = map[int32][]int32{
+	occupancy[0]:  []int32{1 << 1, 1 << 5, 1 << 6},
+	occupancy[1]:  []int32{1 << 0, 1 << 2, 1 << 5, 1 << 6, 1 << 7},
+	occupancy[2]:  []int32{1 << 1, 1 << 3, 1 << 6, 1 << 7, 1 << 8},
+	occupancy[3]:  []int32{1 << 2, 1 << 4, 1 << 7, 1 << 8, 1 << 9},
+	occupancy[4]:  []int32{1 << 3, 1 << 8, 1 << 9},
+	occupancy[5]:  []int32{1 << 0, 1 << 1, 1 << 6, 1 << 10, 1 << 11},
+	occupancy[6]:  []int32{1 << 0, 1 << 1, 1 << 2, 1 << 5, 1 << 7, 1 << 10, 1 << 11, 1 << 12},
+	occupancy[7]:  []int32{1 << 1, 1 << 2, 1 << 3, 1 << 6, 1 << 8, 1 << 11, 1 << 12, 1 << 13},
+	occupancy[8]:  []int32{1 << 2, 1 << 3, 1 << 4, 1 << 7, 1 << 9, 1 << 12, 1 << 13, 1 << 14},
+	occupancy[9]:  []int32{1 << 3, 1 << 4, 1 << 8, 1 << 13, 1 << 14},
+	occupancy[10]: []int32{1 << 5, 1 << 6, 1 << 11, 1 << 15, 1 << 16},
+	occupancy[11]: []int32{1 << 5, 1 << 6, 1 << 7, 1 << 10, 1 << 12, 1 << 15, 1 << 16, 1 << 17},
+	occupancy[12]: []int32{1 << 6, 1 << 7, 1 << 8, 1 << 11, 1 << 13, 1 << 16, 1 << 17, 1 << 18},
+	occupancy[13]: []int32{1 << 7, 1 << 8, 1 << 9, 1 << 12, 1 << 14, 1 << 17, 1 << 18, 1 << 19},
+	occupancy[14]: []int32{1 << 8, 1 << 9, 1 << 13, 1 << 18, 1 << 19},
+	occupancy[15]: []int32{1 << 10, 1 << 11, 1 << 16, 1 << 20, 1 << 21},
+	occupancy[16]: []int32{1 << 10, 1 << 11, 1 << 12, 1 << 15, 1 << 17, 1 << 20, 1 << 21, 1 << 22},
+	occupancy[17]: []int32{1 << 11, 1 << 12, 1 << 13, 1 << 16, 1 << 18, 1 << 21, 1 << 22, 1 << 23},
+	occupancy[18]: []int32{1 << 12, 1 << 13, 1 << 14, 1 << 17, 1 << 19, 1 << 22, 1 << 23, 1 << 24},
+	occupancy[19]: []int32{1 << 13, 1 << 14, 1 << 18, 1 << 23, 1 << 24},
+	occupancy[20]: []int32{1 << 15, 1 << 16, 1 << 21},
+	occupancy[21]: []int32{1 << 15, 1 << 16, 1 << 17, 1 << 20, 1 << 22},
+	occupancy[22]: []int32{1 << 16, 1 << 17, 1 << 18, 1 << 21, 1 << 23},
+	occupancy[23]: []int32{1 << 17, 1 << 18, 1 << 19, 1 << 22, 1 << 24},
+	occupancy[24]: []int32{1 << 18, 1 << 19, 1 << 23},
+}
+
+func legacyLegalMoves(p Position, piece int32) []int32 {
+	mask := ^(p.A | p.B | p.X | p.Y | p.B4)
+	// If you're not at least 1 high, can't go to 2 or 3
+	if piece&p.B1 == 0 {
+		mask = mask &^ (p.B2 | p.B3)
+	}
+	// If you're not at least 2 high, can't go to 3
+	if piece&p.B2 == 0 {
+		mask = mask &^ (p.B3)
+	}
+	//fmt.Printf("MASK %v\n", mask)
+	var ret []int32
+
+	km, ok := legacyKingMoves[piece]
+	if !ok {
+		panic("Map error")
+	}
+
+	// this will be at most 8 ops, often fewer.
+	for _, move := range km {
+		if test := move & mask; test != 0 {
+			ret = append(ret, test)
+		}
+	}
+	return ret
+}
+
+// legalBuilds assumes the piece don't move.
+// so applying to it any but one of the 4 actual pieces
+// returns nonsense.
+func legacyLegalBuilds(p Position, piece int32) []int32 {
+	// Assume you can build anywhere
+	// minus where any of the pieces are, or on a 4 tile.
+	//var mask int32 = 1<<25
+	mask := ((1 << 25) - 1) &^ (p.A | p.B | p.X | p.Y | p.B4)
+	//fmt.Printf("At Position %v, got mask \n%v\n", p.String(), mask)
+
+	var ret []int32
+
+	km, ok := legacyKingMoves[piece]
+	if !ok {
+		panic("Map error")
+	}
+
+	// this will be at most 8 ops, often fewer.
+	for _, move := range km {
+		if test := move & mask; test != 0 {
+			ret = append(ret, test)
+		}
+	}
+	return ret
+}
+
+func legacyLegalBuildMoves(p Position) []MoveBuild {
+	var ret []MoveBuild
+	// If it's white's turn to move
+	var piece1, piece2 int32
+	if !p.Ply {
+		piece1 = p.A
+		piece2 = p.B
+	} else {
+		piece1 = p.X
+		piece2 = p.Y
+	}
+
+	// Don't forget to update the position before you try to get the builds.
+	for _, m := range legacyLegalMoves(p, piece1) {
+		testPiece := p //here it is
+		if !p.Ply {
+			testPiece.A = m
+		} else {
+			testPiece.X = m
+		}
+		legalbuilds := legacyLegalBuilds(testPiece, m)
+		// Don't consider a move if there are no builds from it.
+		if len(legalbuilds) == 0 {
+			continue
+		}
+		for _, b := range legacyLegalBuilds(testPiece, m) {
+			ret = append(ret, MoveBuild{m, b, p.Ply, false})
+		}
+	}
+	for _, m := range legacyLegalMoves(p, piece2) {
+		testPiece := p
+		if !p.Ply {
+			testPiece.B = m
+		} else {
+			testPiece.Y = m
+		}
+		// Don't consider a move if there are no builds from it.
+		legalbuilds := legacyLegalBuilds(testPiece, m)
+		if len(legalbuilds) == 0 {
+			continue
+		}
+		for _, b := range legacyLegalBuilds(testPiece, m) {
+			ret = append(ret, MoveBuild{m, b, p.Ply, true})
+		}
+	}
+	return ret
+}
+
+func TestAppendMovesMatchesLegacy(t *testing.T) {
+	positions := []string{
+		"|0400300002001303040111124|05080018|",
+		"|0400300002001303041111124|05080018|",
+		"|0102000100443440032100000|00081922|",
+		"|2200000110444332000000001|00071620|",
+	}
+	for _, s := range positions {
+		position, e := NewPosition(s)
+		if e != nil {
+			t.Errorf("Error forming position")
+		}
+		var walk func(p Position, depth int)
+		walk = func(p Position, depth int) {
+			want := legacyLegalBuildMoves(p)
+			got := AppendMoves(nil, p)
+			if len(want) != len(got) {
+				t.Fatalf("%v: expected %v moves, got %v", p, len(want), len(got))
+			}
+			for i := range want {
+				if want[i] != got[i] {
+					t.Fatalf("%v: move %v, expected %+v, got %+v", p, i, want[i], got[i])
+				}
+			}
+			if depth == 0 {
+				return
+			}
+			for _, mb := range got {
+				walk(UpdatePosition(p, mb), depth-1)
+			}
+		}
+		walk(position, 2)
+	}
+}
+
+func TestAppendMovesDoesNotAllocate(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	buf := make([]MoveBuild, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendMoves(buf[:0], position)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+	if len(buf) != len(legacyLegalBuildMoves(position)) {
+		t.Fatalf("expected %v moves, got %v", len(legacyLegalBuildMoves(position)), len(buf))
+	}
+}
+
+func BenchmarkLegacyLegalBuildMoves(b *testing.B) {
+	position, _ := NewPosition("|0400300002001303040111124|05080018|")
+	for i := 0; i < b.N; i++ {
+		legacyLegalBuildMoves(position)
+	}
+}
+
+func BenchmarkLegalBuildMoves(b *testing.B) {
+	position, _ := NewPosition("|0400300002001303040111124|05080018|")
+	for i := 0; i < b.N; i++ {
+		LegalBuildMoves(position)
+	}
+}
+
+func BenchmarkAppendMoves(b *testing.B) {
+	position, _ := NewPosition("|0400300002001303040111124|05080018|")
+	buf := make([]MoveBuild, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendMoves(buf[:0], position)
+	}
+}
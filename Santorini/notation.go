@@ -0,0 +1,206 @@
+package Santorini
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Notation is a way of writing squares in moves. Either way, a move is the
+// worker's letter, where it moves from and to, and where it builds:
+//
+//	A 11-12^7     B c1-d2^e3
+//
+// White's workers are A and B and Black's are X and Y, the first of each
+// pair being the one on the lower numbered square. Placing workers is
+// written as each worker and its square:
+//
+//	A 7 B 12
+type Notation int
+
+const (
+	// Numeric writes squares as their numbers, 0 to 24.
+	Numeric Notation = iota
+	// Coordinates writes squares as a file, a to e from left to right, and
+	// a rank, 1 to 5 from top to bottom, so square 0 is a1 and 12 is c3.
+	Coordinates
+)
+
+var (
+	ErrBadMove     = errors.New("expected a worker, squares and a build, such as A 11-12^7")
+	ErrIllegalMove = errors.New("move isn't legal in this position")
+)
+
+// MoveError describes a move that couldn't be parsed.
+type MoveError struct {
+	Input string // The text of the move.
+	Err   error  // ErrBadMove or ErrIllegalMove.
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("santorini: bad move %q: %v", e.Input, e.Err)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+// Square writes square sq in notation n.
+func (n Notation) Square(sq int) string {
+	if n == Coordinates {
+		return string(rune('a'+sq%5)) + strconv.Itoa(sq/5+1)
+	}
+	return strconv.Itoa(sq)
+}
+
+// parseSquare reads a square in either notation.
+func parseSquare(s string) (int, bool) {
+	if len(s) == 2 && s[0] >= 'a' && s[0] <= 'e' && s[1] >= '1' && s[1] <= '5' {
+		return int(s[1]-'1')*5 + int(s[0]-'a'), true
+	}
+	sq, err := strconv.Atoi(s)
+	if err != nil || sq < 0 || sq > 24 || s != strconv.Itoa(sq) {
+		return 0, false
+	}
+	return sq, true
+}
+
+// workerLetter is the letter for the first or second worker of a side.
+func workerLetter(ply, second bool) string {
+	return [2][2]string{{"A", "B"}, {"X", "Y"}}[boolIndex(ply)][boolIndex(second)]
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Format writes mb, a move from p, in notation n.
+func (n Notation) Format(p Position, mb MoveBuild) string {
+	if p.Placing {
+		first := mb.Move & -mb.Move
+		second := mb.Move &^ first
+		return workerLetter(mb.Ply, false) + " " + n.Square(square(first)) + " " +
+			workerLetter(mb.Ply, true) + " " + n.Square(square(second))
+	}
+	from := p.worker(mb.Ply, mb.Piece)
+	return workerLetter(mb.Ply, mb.Piece) + " " + n.Square(square(from)) + "-" +
+		n.Square(square(mb.Move)) + "^" + n.Square(square(mb.Build))
+}
+
+// ParseMove reads a move for the side to move in p, in either notation, and
+// returns it if it is legal. The build can be left off a move that wins,
+// since the game is over before it happens.
+func ParseMove(p Position, s string) (MoveBuild, error) {
+	fields := strings.Fields(s)
+	mb, used, err := parseMoveFields(p, fields)
+	if err == nil && used != len(fields) {
+		err = ErrBadMove
+	}
+	if err != nil {
+		return MoveBuild{}, &MoveError{Input: s, Err: err}
+	}
+	return mb, nil
+}
+
+// ParseMoves reads a list of moves separated by spaces, played one after
+// another from p, such as "A 6-7^2 X 8-9^3". Each must be legal where it is
+// played. The moves before a bad one are returned with the error.
+func ParseMoves(p Position, s string) ([]MoveBuild, error) {
+	var moves []MoveBuild
+	for fields := strings.Fields(s); len(fields) > 0; {
+		mb, used, err := parseMoveFields(p, fields)
+		if err != nil {
+			n := moveFields(p)
+			if n > len(fields) {
+				n = len(fields)
+			}
+			return moves, &MoveError{Input: strings.Join(fields[:n], " "), Err: err}
+		}
+		moves = append(moves, mb)
+		p = UpdatePosition(p, mb)
+		fields = fields[used:]
+	}
+	return moves, nil
+}
+
+// moveFields is how many fields a move for p takes: a letter and a square
+// for each worker being placed, or a letter and the squares of the move.
+func moveFields(p Position) int {
+	if p.Placing {
+		return 4
+	}
+	return 2
+}
+
+// parseMoveFields reads a move for p from the start of fields, and returns
+// it with the number of fields it used up.
+func parseMoveFields(p Position, fields []string) (MoveBuild, int, error) {
+	n := moveFields(p)
+	if len(fields) < n {
+		return MoveBuild{}, 0, ErrBadMove
+	}
+	if p.Placing {
+		mb, err := parsePlacement(p, fields[:n])
+		return mb, n, err
+	}
+	mb, err := parseWorkerMove(p, fields[0], fields[1])
+	return mb, n, err
+}
+
+func parsePlacement(p Position, fields []string) (MoveBuild, error) {
+	var squares int32
+	for i := 0; i < 4; i += 2 {
+		if fields[i] != workerLetter(p.Ply, i == 2) {
+			return MoveBuild{}, ErrBadMove
+		}
+		sq, ok := parseSquare(fields[i+1])
+		if !ok {
+			return MoveBuild{}, ErrBadMove
+		}
+		squares |= occupancy[sq]
+	}
+	for _, mb := range LegalBuildMoves(p) {
+		if mb.Move == squares {
+			return mb, nil
+		}
+	}
+	return MoveBuild{}, ErrIllegalMove
+}
+
+func parseWorkerMove(p Position, letter, squares string) (MoveBuild, error) {
+	var second bool
+	switch letter {
+	case workerLetter(p.Ply, false):
+	case workerLetter(p.Ply, true):
+		second = true
+	default:
+		return MoveBuild{}, ErrBadMove
+	}
+	fromTo, buildSquare, hasBuild := strings.Cut(squares, "^")
+	fromSquare, toSquare, ok := strings.Cut(fromTo, "-")
+	if !ok {
+		return MoveBuild{}, ErrBadMove
+	}
+	from, ok1 := parseSquare(fromSquare)
+	to, ok2 := parseSquare(toSquare)
+	build, ok3 := parseSquare(buildSquare)
+	if !ok1 || !ok2 || hasBuild && !ok3 {
+		return MoveBuild{}, ErrBadMove
+	}
+	if p.worker(p.Ply, second) != occupancy[from] {
+		return MoveBuild{}, ErrIllegalMove
+	}
+	for _, mb := range LegalBuildMoves(p) {
+		if mb.Piece != second || mb.Move != occupancy[to] {
+			continue
+		}
+		if hasBuild && mb.Build == occupancy[build] || !hasBuild && winningMove(p, mb) {
+			return mb, nil
+		}
+	}
+	return MoveBuild{}, ErrIllegalMove
+}
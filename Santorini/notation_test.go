@@ -0,0 +1,119 @@
+package Santorini
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSquareNotation(t *testing.T) {
+	for sq, want := range map[int]string{0: "a1", 4: "e1", 12: "c3", 20: "a5", 24: "e5"} {
+		if got := Coordinates.Square(sq); got != want {
+			t.Fatalf("square %v: expected %v, got %v", sq, want, got)
+		}
+		if got, ok := parseSquare(want); !ok || got != sq {
+			t.Fatalf("%v: expected square %v, got %v", want, sq, got)
+		}
+	}
+	for _, bad := range []string{"", "25", "-1", "f1", "a6", "07", "c"} {
+		if _, ok := parseSquare(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestMoveNotationRoundTrip(t *testing.T) {
+	positions := []string{
+		"|0400300002001303040111124|05080018|",
+		"|0102000100443440032100000|00081922|",
+		"|0000000000000000000000000|07121117|w|",
+	}
+	for _, s := range positions {
+		position, e := NewPosition(s)
+		if e != nil {
+			t.Fatalf("Error forming position: %v", e)
+		}
+		for _, mb := range LegalBuildMoves(position) {
+			for _, n := range []Notation{Numeric, Coordinates} {
+				text := n.Format(position, mb)
+				got, err := ParseMove(position, text)
+				if err != nil || got != mb {
+					t.Fatalf("%v: %q read back as %+v, %v, not %+v", s, text, got, err, mb)
+				}
+			}
+		}
+	}
+	// Placing workers too.
+	game := NewGame()
+	mb := LegalBuildMoves(game)[10]
+	text := Numeric.Format(game, mb)
+	if text != "A 0 B 11" {
+		t.Fatalf("expected A 0 B 11, got %q", text)
+	}
+	if got, err := ParseMove(game, text); err != nil || got != mb {
+		t.Fatalf("%q read back as %+v, %v", text, got, err)
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	// White's workers are on 7 and 12, Black's on 11 and 17.
+	position, e := NewPosition("|0000000000000000000000000|07121117|w|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	mb, err := ParseMove(position, "B 12-13^14")
+	if err != nil || mb != (MoveBuild{occupancy[13], occupancy[14], false, true}) {
+		t.Fatalf("unexpected move %+v, %v", mb, err)
+	}
+	if same, err := ParseMove(position, "  B c3-d3^e3 "); err != nil || same != mb {
+		t.Fatalf("coordinates gave %+v, %v", same, err)
+	}
+
+	for s, want := range map[string]error{
+		"":              ErrBadMove,
+		"B 12-13":       ErrIllegalMove, // Only a winning move can leave off the build.
+		"B 12-13^14 A":  ErrBadMove,
+		"X 11-10^5":     ErrBadMove, // Black's worker, but White to move.
+		"A 12-13^14":    ErrIllegalMove,
+		"B 12-17^16":    ErrIllegalMove,
+		"B 12-13^12^14": ErrBadMove,
+		"B 12_13^14":    ErrBadMove,
+	} {
+		_, err := ParseMove(position, s)
+		var me *MoveError
+		if !errors.As(err, &me) || me.Input != s || !errors.Is(err, want) {
+			t.Fatalf("%q: expected %v, got %v", s, want, err)
+		}
+	}
+
+	// The piece on 17 can climb onto 12, and win without building.
+	position, e = NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	if mb, err := ParseMove(position, "X 17-12"); err != nil || !winningMove(position, mb) {
+		t.Fatalf("expected the winning climb, got %+v, %v", mb, err)
+	}
+}
+
+func TestParseMoves(t *testing.T) {
+	moves, err := ParseMoves(NewGame(), "A 6 B 12 X 8 Y 16 A 6-7^2 X d2-e2^e1")
+	if err != nil || len(moves) != 4 {
+		t.Fatalf("expected four moves, got %v, %v", moves, err)
+	}
+	if moves[3] != (MoveBuild{occupancy[9], occupancy[4], true, false}) {
+		t.Fatalf("unexpected last move %+v", moves[3])
+	}
+	if moves, err := ParseMoves(NewGame(), ""); err != nil || len(moves) != 0 {
+		t.Fatalf("expected no moves, got %v, %v", moves, err)
+	}
+
+	// The moves up to the bad one come back with the error.
+	moves, err = ParseMoves(NewGame(), "A 6 B 12 X 6 Y 16")
+	var me *MoveError
+	if len(moves) != 1 || !errors.As(err, &me) || me.Input != "X 6 Y 16" || !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("expected the third placement to be illegal, got %v, %v", moves, err)
+	}
+	if _, err := ParseMoves(NewGame(), "A 6 B"); !errors.Is(err, ErrBadMove) {
+		t.Fatalf("expected a short move to be bad, got %v", err)
+	}
+}
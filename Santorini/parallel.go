@@ -0,0 +1,69 @@
+package Santorini
+
+import (
+	"context"
+	"sync"
+)
+
+// helpers are the extra threads of a parallel search. They use lazy SMP:
+// each one runs its own iterative deepening search of the root, sharing
+// nothing with the others but the transposition table. Their results are
+// thrown away, but for their stats. What they find reaches the main thread
+// through the table, as cutoffs and better move ordering.
+type helpers struct {
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	searches []*Searcher
+	// nodes counts every thread's nodes as they go, so they can keep to a
+	// node budget together.
+	nodes int64
+}
+
+// startHelpers sets s.Threads-1 helpers searching p until ctx is cancelled
+// or stop is called. It returns nil when s searches on one thread.
+func (s *Searcher) startHelpers(ctx context.Context, p Position) *helpers {
+	if s.Threads <= 1 {
+		return nil
+	}
+	h := &helpers{}
+	s.budget.shared = &h.nodes
+	b := s.budget
+	b.ctx, h.cancel = context.WithCancel(ctx)
+	for i := 1; i < s.Threads; i++ {
+		helper := &Searcher{
+			Table:      s.Table,
+			Evaluator:  s.Evaluator,
+			Tablebases: s.Tablebases,
+			limited:    true,
+			budget:     b,
+		}
+		h.searches = append(h.searches, helper)
+		h.wg.Add(1)
+		// Odd helpers start a ply deeper, so the threads aren't all
+		// working on the same iteration at once.
+		go func(helper *Searcher, first int) {
+			defer h.wg.Done()
+			for depth := first; depth <= MaxDepth && !helper.aborted; depth++ {
+				helper.searchRoot(p, depth)
+			}
+		}(helper, 1+i%2)
+	}
+	return h
+}
+
+// stop stops the helpers, waits for them to finish, and returns what they
+// did between them.
+func (h *helpers) stop() SearchStats {
+	var total SearchStats
+	if h == nil {
+		return total
+	}
+	h.cancel()
+	h.wg.Wait()
+	for _, helper := range h.searches {
+		stats := helper.stats
+		stats.Nodes = helper.nodes
+		total.add(stats)
+	}
+	return total
+}
@@ -0,0 +1,96 @@
+package Santorini
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestTableConcurrentUse(t *testing.T) {
+	tt := NewTranspositionTable(64)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				// Every goroutine fights over the same few slots. Whatever
+				// a probe finds has to be what was stored for that key.
+				key := uint64(i%256)*0x9e3779b97f4a7c15 + 1
+				tt.store(key, g%4, BoundExact, int(key%1000), int(key%100))
+				if e, ok := tt.probe(key); ok && (int(e.score) != int(key%1000) || int(e.move) != int(key%100)+1) {
+					t.Errorf("probe of %x returned a torn entry %+v", key, e)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestParallelSearch(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	s := NewSearcher()
+	s.Threads = 4
+	got := s.Search(position, 3)
+	p := position
+	for i, mb := range got.PV {
+		legal := false
+		for _, m := range LegalBuildMoves(p) {
+			if m == mb {
+				legal = true
+			}
+		}
+		if !legal {
+			t.Fatalf("move %v of the principal variation, %+v, is illegal in %v", i, mb, p)
+		}
+		p = UpdatePosition(p, mb)
+	}
+	if len(got.PV) == 0 || got.Nodes == 0 {
+		t.Fatalf("expected a line and some nodes, got %+v", got)
+	}
+
+	// The helpers don't get in the way of finding a win.
+	position, e = NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got = s.IterativeSearch(context.Background(), position, Limits{}, nil)
+	if got.Score != WinScore-1 || got.Move.Move != occupancy[12] {
+		t.Fatalf("expected the climb to 12, got %+v", got)
+	}
+}
+
+func TestParallelNodeBudget(t *testing.T) {
+	position, e := NewPosition("|0000000000000000000000000|07121117|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	s := NewSearcher()
+	s.Threads = 4
+	got := s.IterativeSearch(context.Background(), position, Limits{Nodes: 20000}, nil)
+	// Helpers report their nodes 1024 at a time, and only look at whether
+	// to stop every 1024 nodes, so each can run over by up to twice that.
+	if got.Nodes > 20000+3*2*1024+len(LegalBuildMoves(position))+1 {
+		t.Fatalf("node budget of 20000 overrun: %v", got.Nodes)
+	}
+}
+
+func TestSingleThreadDeterministic(t *testing.T) {
+	position, e := NewPosition("|0000000000000000000000000|07121117|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	search := func() SearchResult {
+		s := NewSearcher()
+		s.Threads = 1
+		return s.IterativeSearch(context.Background(), position, Limits{Nodes: 20000}, nil)
+	}
+	a, b := search(), search()
+	if a.Move != b.Move || a.Score != b.Score || a.Nodes != b.Nodes || a.Depth != b.Depth {
+		t.Fatalf("single threaded searches differ: %+v and %+v", a, b)
+	}
+}
@@ -0,0 +1,56 @@
+package Santorini
+
+// PerftEntry is the node count below one root move.
+type PerftEntry struct {
+	Move  MoveBuild
+	Nodes uint64
+}
+
+// Perft counts the leaves of the game tree depth plies below p: every
+// sequence of depth legal moves, placements included. A winning move ends
+// the game, so it counts as a leaf on the last ply and adds nothing before
+// that. Comparing counts against known values is the quickest way to catch
+// a change that breaks move generation or UpdatePosition.
+func Perft(p Position, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	return perft(p, depth, make([][]MoveBuild, depth))
+}
+
+// PerftDivide breaks Perft down by root move, in move generation order, so
+// a wrong count can be chased down to the move that causes it.
+func PerftDivide(p Position, depth int) []PerftEntry {
+	if depth <= 0 {
+		return nil
+	}
+	buffers := make([][]MoveBuild, depth)
+	var ret []PerftEntry
+	for _, mb := range LegalBuildMoves(p) {
+		var n uint64 = 1
+		if depth > 1 {
+			n = 0
+			if !winningMove(p, mb) {
+				n = perft(UpdatePosition(p, mb), depth-1, buffers)
+			}
+		}
+		ret = append(ret, PerftEntry{mb, n})
+	}
+	return ret
+}
+
+func perft(p Position, depth int, buffers [][]MoveBuild) uint64 {
+	moves := AppendMoves(buffers[depth-1][:0], p)
+	buffers[depth-1] = moves
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, mb := range moves {
+		if winningMove(p, mb) {
+			continue
+		}
+		n += perft(UpdatePosition(p, mb), depth-1, buffers)
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+// Known leaf counts. If move generation or UpdatePosition changes on
+// purpose, check the new numbers against an independent count before
+// updating them here.
+var perftPositions = []struct {
+	position string
+	want     []uint64 // Counts for depth 1, 2, 3...
+}{
+	{"|0000000000000000000000000|--------|", []uint64{300, 75900}},
+	{"|0000000000000000000000000|07121117|", []uint64{66, 4360, 296637}},
+	{"|0400300002001303040111124|05080018|", []uint64{44, 997, 41792}},
+	{"|0400300002001303041111124|05080018|", []uint64{27, 1074, 28486}},
+	{"|1002000100443440022100001|01081723|", []uint64{35, 1074, 30290}},
+	{"|0102000100443440032100000|00081922|b|", []uint64{27, 833, 20237}},
+	{"|2200000110444332000000001|00071620|", []uint64{19, 683, 19046}},
+}
+
+func TestPerft(t *testing.T) {
+	for _, tc := range perftPositions {
+		position, e := NewPosition(tc.position)
+		if e != nil {
+			t.Fatalf("%v: %v", tc.position, e)
+		}
+		for i, want := range tc.want {
+			if got := Perft(position, i+1); got != want {
+				t.Fatalf("%v at depth %v: expected %v, got %v", tc.position, i+1, want, got)
+			}
+		}
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	for _, tc := range perftPositions {
+		position, e := NewPosition(tc.position)
+		if e != nil {
+			t.Fatalf("%v: %v", tc.position, e)
+		}
+		depth := len(tc.want)
+		divide := PerftDivide(position, depth)
+		if len(divide) != int(tc.want[0]) {
+			t.Fatalf("%v: expected %v root moves, got %v", tc.position, tc.want[0], len(divide))
+		}
+		var total uint64
+		for i, entry := range divide {
+			if entry.Move != LegalBuildMoves(position)[i] {
+				t.Fatalf("%v: divide out of move generation order at %v", tc.position, i)
+			}
+			total += entry.Nodes
+		}
+		if total != tc.want[depth-1] {
+			t.Fatalf("%v: divide adds up to %v, expected %v", tc.position, total, tc.want[depth-1])
+		}
+	}
+}
+
+// legacyPerft counts the same tree with the old map based move generator.
+func legacyPerft(p Position, depth int) uint64 {
+	moves := legacyLegalBuildMoves(p)
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, mb := range moves {
+		if !winningMove(p, mb) {
+			n += legacyPerft(UpdatePosition(p, mb), depth-1)
+		}
+	}
+	return n
+}
+
+func TestPerftMatchesLegacy(t *testing.T) {
+	for _, tc := range perftPositions[1:] {
+		position, e := NewPosition(tc.position)
+		if e != nil {
+			t.Fatalf("%v: %v", tc.position, e)
+		}
+		if got, want := Perft(position, 3), legacyPerft(position, 3); got != want {
+			t.Fatalf("%v: expected %v, got %v", tc.position, want, got)
+		}
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	position, _ := NewPosition("|0400300002001303040111124|05080018|")
+	for i := 0; i < b.N; i++ {
+		Perft(position, 3)
+	}
+}
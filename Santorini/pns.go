@@ -0,0 +1,233 @@
+package Santorini
+
+import (
+	"time"
+)
+
+// pnInfinity is the proof or disproof number of a node that can't be proven
+// or disproven. Sums stop there rather than overflow.
+const pnInfinity = 1 << 40
+
+// ProofSearch decides GameNodes exactly with proof-number search. Unlike
+// ExploreNode, it only says who wins once it is sure, and it spends its
+// effort on the moves that look closest to settling the question: those
+// with the fewest unsolved replies.
+//
+// Zero limits don't limit anything.
+type ProofSearch struct {
+	// MaxNodes is the most nodes to expand, that is, to ask for Children.
+	MaxNodes int
+	// MaxTreeSize is the most nodes to keep in memory at once. Subtrees
+	// are thrown away as soon as they are solved, keeping only the moves
+	// on the winning line, so a search often fits in far fewer nodes than
+	// it expands.
+	MaxTreeSize int
+	// Tracer, if not nil, hears about each node expanded, and gets the
+	// stats every 1024 expansions.
+	Tracer Tracer
+}
+
+// ProofResult is the outcome of a ProofSearch.
+type ProofResult struct {
+	// Proven is set when the search decided the root within its limits.
+	Proven bool
+	// Winner is 'W' or 'B' when Proven, otherwise '?'.
+	Winner rune
+	// Line is a game from the root, one node per move, with the winner
+	// playing moves that win and the loser moves that don't save them. It
+	// ends at a node whose Outcome is decided, or where the side to move
+	// is stuck, and is empty when the root is one.
+	Line []GameNode
+	// Expanded is the number of nodes expanded.
+	Expanded int
+	// TreeSize is the most nodes kept in memory at once.
+	TreeSize int
+	// Stats counts the nodes added to the tree. Cutoffs counts the solved
+	// nodes trimmed back to one move.
+	Stats SearchStats
+}
+
+type pnsNode struct {
+	state    GameNode
+	parent   *pnsNode
+	children []*pnsNode
+	ply      int
+	// or is set on the nodes where the side to move at the root is to move.
+	or       bool
+	expanded bool
+	proof    int
+	disproof int
+}
+
+func (n *pnsNode) solved() bool {
+	return n.proof == 0 || n.disproof == 0
+}
+
+// Prove searches root until it is decided or the limits are reached.
+func (s ProofSearch) Prove(root GameNode) ProofResult {
+	// Proving the root means the side to move there wins.
+	side := root.WhichPly()
+	winner, loser := 'W', 'B'
+	if side {
+		winner, loser = loser, winner
+	}
+
+	start := time.Now()
+	size := 1
+	r := ProofResult{Winner: '?', TreeSize: size, Stats: SearchStats{Nodes: 1}}
+	tree := s.newNode(root, nil, side, winner)
+	for !tree.solved() {
+		if s.Tracer != nil && r.Expanded > 0 && r.Expanded%1024 == 0 {
+			r.Stats.Elapsed = time.Since(start)
+			s.Tracer.Progress(r.Stats)
+		}
+		if s.MaxNodes > 0 && r.Expanded >= s.MaxNodes {
+			break
+		}
+		if s.MaxTreeSize > 0 && size >= s.MaxTreeSize {
+			break
+		}
+		n := tree
+		for n.expanded {
+			n = mostProving(n)
+		}
+		if s.Tracer != nil {
+			s.Tracer.Visit(n.state, n.ply)
+		}
+		for _, c := range n.state.Children() {
+			n.children = append(n.children, s.newNode(c, n, side, winner))
+		}
+		n.expanded = true
+		r.Expanded++
+		r.Stats.Expanded++
+		r.Stats.Children += len(n.children)
+		r.Stats.Nodes += len(n.children)
+		if len(n.children) > 0 {
+			r.Stats.reach(n.ply + 1)
+		}
+		size += len(n.children)
+		if size > r.TreeSize {
+			r.TreeSize = size
+		}
+		freed, trimmed := update(n)
+		size -= freed
+		r.Stats.Cutoffs += trimmed
+	}
+	r.Stats.Elapsed = time.Since(start)
+	if s.Tracer != nil {
+		s.Tracer.Progress(r.Stats)
+	}
+
+	if !tree.solved() {
+		return r
+	}
+	r.Proven = true
+	r.Winner = winner
+	if tree.proof != 0 {
+		r.Winner = loser
+	}
+	for n := tree; len(n.children) > 0; {
+		n = n.children[0]
+		r.Line = append(r.Line, n.state)
+	}
+	return r
+}
+
+// newNode makes a node for state. Decided states are solved straight away:
+// proven if winner, the side to move at the root, has won.
+func (s ProofSearch) newNode(state GameNode, parent *pnsNode, side bool, winner rune) *pnsNode {
+	n := &pnsNode{state: state, parent: parent, or: state.WhichPly() == side, proof: 1, disproof: 1}
+	if parent != nil {
+		n.ply = parent.ply + 1
+	}
+	switch state.Outcome() {
+	case '?':
+		return n
+	case winner:
+		n.proof, n.disproof = 0, pnInfinity
+	default:
+		n.proof, n.disproof = pnInfinity, 0
+	}
+	n.expanded = true
+	return n
+}
+
+// mostProving picks the child of n to follow towards the most proving node:
+// the one that sets n's proof number at an OR node, or its disproof number
+// at an AND node.
+func mostProving(n *pnsNode) *pnsNode {
+	for _, c := range n.children {
+		if n.or && c.proof == n.proof || !n.or && c.disproof == n.disproof {
+			return c
+		}
+	}
+	return n.children[0]
+}
+
+// update recomputes the proof and disproof numbers of n and its ancestors.
+// It returns how many nodes it freed by trimming solved subtrees, and how
+// many nodes it trimmed.
+func update(n *pnsNode) (freed, trimmed int) {
+	for ; n != nil; n = n.parent {
+		proof, disproof := pnInfinity, 0
+		if !n.or {
+			proof, disproof = 0, pnInfinity
+		}
+		for _, c := range n.children {
+			if n.or {
+				proof = minPN(proof, c.proof)
+				disproof = minPN(disproof+c.disproof, pnInfinity)
+			} else {
+				proof = minPN(proof+c.proof, pnInfinity)
+				disproof = minPN(disproof, c.disproof)
+			}
+		}
+		n.proof, n.disproof = proof, disproof
+		if n.solved() && len(n.children) > 1 {
+			freed += trim(n)
+			trimmed++
+		}
+	}
+	return freed, trimmed
+}
+
+// trim cuts a solved node down to one child, one that shows why it is
+// solved, and returns the number of nodes that went with the rest. Where
+// the winner is to move that has to be a winning move, and where the loser
+// is, every move loses and the first will do.
+func trim(n *pnsNode) int {
+	if len(n.children) == 0 {
+		return 0
+	}
+	keep := n.children[0]
+	for _, c := range n.children {
+		if n.proof == 0 && c.proof == 0 || n.disproof == 0 && c.disproof == 0 {
+			keep = c
+			break
+		}
+	}
+	freed := 0
+	for _, c := range n.children {
+		if c != keep {
+			freed += treeSize(c)
+		}
+	}
+	n.children = []*pnsNode{keep}
+	return freed
+}
+
+// treeSize counts n and everything below it.
+func treeSize(n *pnsNode) int {
+	size := 1
+	for _, c := range n.children {
+		size += treeSize(c)
+	}
+	return size
+}
+
+func minPN(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
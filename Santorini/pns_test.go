@@ -0,0 +1,92 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+// checkLine makes sure each node of line is a child of the one before, and
+// that the line ends with the game won by winner.
+func checkLine(t *testing.T, root GameNode, line []GameNode, winner rune) {
+	t.Helper()
+	n := root
+	for i, next := range line {
+		found := false
+		for _, c := range n.Children() {
+			if c.String() == next.String() {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("move %v of the line, to %v, isn't a move from %v", i, next, n)
+		}
+		n = next
+	}
+	if got := n.Outcome(); got != winner && !(got == '?' && len(n.Children()) == 0 && mover(n) == winner) {
+		t.Fatalf("line ends at %v, outcome %c, not a win for %c", n, got, winner)
+	}
+}
+
+func TestProveMockTree(t *testing.T) {
+	root := mockTree()
+	got := ProofSearch{}.Prove(root)
+	if !got.Proven || got.Winner != 'W' {
+		t.Fatalf("expected White to win, got %+v", got)
+	}
+	if len(got.Line) != 2 || got.Line[0].String() != "safe" {
+		t.Fatalf("expected the line to go through safe, got %v", got.Line)
+	}
+	checkLine(t, root, got.Line, 'W')
+
+	// Without the safe move, every line loses for White.
+	root.Kids = root.Kids[:2]
+	got = ProofSearch{}.Prove(root)
+	if !got.Proven || got.Winner != 'B' {
+		t.Fatalf("expected Black to win, got %+v", got)
+	}
+	checkLine(t, root, got.Line, 'B')
+}
+
+func TestProveScore1(t *testing.T) {
+	// The position from TestScore1, which ExploreNode finds Black wins.
+	position, e := NewPosition("|0102000100443440032100000|00081922|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := ProofSearch{MaxNodes: 100000}.Prove(position)
+	if !got.Proven || got.Winner != 'B' {
+		t.Fatalf("expected Black to win, got %+v", got)
+	}
+	checkLine(t, position, got.Line, 'B')
+}
+
+func TestProveDecidedRoot(t *testing.T) {
+	// Black can climb onto 12.
+	position, e := NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := ProofSearch{}.Prove(position)
+	if !got.Proven || got.Winner != 'B' || len(got.Line) != 0 || got.Expanded != 0 {
+		t.Fatalf("expected a win for Black without searching, got %+v", got)
+	}
+}
+
+func TestProveLimits(t *testing.T) {
+	position, e := NewPosition("|0000000000000000000000000|07121117|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := ProofSearch{MaxNodes: 50}.Prove(position)
+	if got.Proven || got.Winner != '?' || got.Expanded != 50 {
+		t.Fatalf("expected to stop unproven after 50 expansions, got %+v", got)
+	}
+	got = ProofSearch{MaxTreeSize: 1000}.Prove(position)
+	if got.Proven {
+		t.Fatalf("expected to stop unproven, got %+v", got)
+	}
+	// One expansion can take the tree past the limit, by at most the 2*8*8
+	// moves a position can have.
+	if got.TreeSize > 1000+2*8*8 {
+		t.Fatalf("tree grew to %v nodes", got.TreeSize)
+	}
+}
@@ -0,0 +1,356 @@
+package Santorini
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Game is the record of one game: tags describing it, where it started,
+// and the moves played from there.
+//
+// Games are read and written in a format modelled on chess PGN. Tags come
+// first, one to a line, then a blank line, then the moves, numbered once
+// for each pair of White and Black moves, and the result:
+//
+//	[White "Ann"]
+//	[Black "Bob"]
+//	[Date "2024.05.01"]
+//	[Result "*"]
+//
+//	1. A 6 B 12 X 8 Y 16 2. A 6-7^2 X 8-9^3 3. B 12-13^14 *
+//
+// A Position tag holds the starting position, in the form NewPosition
+// reads, for games that don't start from the beginning.
+type Game struct {
+	Tags  map[string]string
+	Start Position
+	Moves []MoveBuild
+}
+
+// Results, as written in the Result tag and at the end of the moves.
+const (
+	WhiteWins  = "1-0"
+	BlackWins  = "0-1"
+	Unfinished = "*"
+)
+
+// tagOrder is the order tags are written in. Any others follow in
+// alphabetical order.
+var tagOrder = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result", "Position"}
+
+var ErrBadRecord = errors.New("malformed game record")
+
+// RecordError describes a problem reading a game record.
+type RecordError struct {
+	Line int   // Line of the input the problem is on, from 1.
+	Err  error // ErrBadRecord, or the error from reading a move or position.
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("santorini: game record line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// NewGameRecord starts a record of a game from the beginning.
+func NewGameRecord() *Game {
+	return &Game{Tags: map[string]string{}, Start: NewGame()}
+}
+
+// Position is where the game has got to.
+func (g *Game) Position() Position {
+	p := g.Start
+	for _, mb := range g.Moves {
+		p = UpdatePosition(p, mb)
+	}
+	return p
+}
+
+// Play adds mb to the game, if it is legal and the game isn't over.
+func (g *Game) Play(mb MoveBuild) error {
+	if g.Ended().Over() {
+		return ErrIllegalMove
+	}
+	p := g.Position()
+	for _, m := range LegalBuildMoves(p) {
+		if m == mb {
+			g.Moves = append(g.Moves, mb)
+			return nil
+		}
+	}
+	return ErrIllegalMove
+}
+
+// Result is the result of the game so far: WhiteWins, BlackWins or
+// Unfinished. A win played out in the moves counts, and otherwise the
+// Result tag does, for games that ended by resignation or on time.
+func (g *Game) Result() string {
+	if r := g.Ended(); r.Over() {
+		if r.Winner {
+			return BlackWins
+		}
+		return WhiteWins
+	}
+	switch tag := g.Tags["Result"]; tag {
+	case WhiteWins, BlackWins:
+		return tag
+	}
+	return Unfinished
+}
+
+// Ended is how the moves played ended the game, if they did: with a climb,
+// or with the side to move left without a move.
+func (g *Game) Ended() Result {
+	p := g.Start
+	for _, mb := range g.Moves {
+		if r := p.ResultAfter(mb); r.Over() {
+			return r
+		}
+		p = UpdatePosition(p, mb)
+	}
+	if len(LegalBuildMoves(p)) == 0 {
+		return p.Result()
+	}
+	return Result{}
+}
+
+// GameWriter writes games to an io.Writer.
+type GameWriter struct {
+	w *bufio.Writer
+	// Notation is how moves are written. The default is Numeric.
+	Notation Notation
+}
+
+func NewGameWriter(w io.Writer) *GameWriter {
+	return &GameWriter{w: bufio.NewWriter(w)}
+}
+
+// maxLine is where the moves are wrapped.
+const maxLine = 79
+
+// Write writes g, followed by a blank line to separate it from the next.
+func (gw *GameWriter) Write(g *Game) error {
+	result := g.Result()
+	tags := make(map[string]string, len(g.Tags)+2)
+	for k, v := range g.Tags {
+		tags[k] = v
+	}
+	tags["Result"] = result
+	if g.Start.String() != NewGame().String() {
+		tags["Position"] = g.Start.String()
+	}
+	var keys []string
+	for _, k := range tagOrder {
+		if _, ok := tags[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var others []string
+	for k := range tags {
+		if !contains(tagOrder, k) {
+			others = append(others, k)
+		}
+	}
+	sort.Strings(others)
+	for _, k := range append(keys, others...) {
+		fmt.Fprintf(gw.w, "[%s %s]\n", k, strconv.Quote(tags[k]))
+	}
+	gw.w.WriteString("\n")
+
+	var tokens []string
+	p := g.Start
+	number := p.MoveNumber
+	if number == 0 {
+		number = 1
+	}
+	for i, mb := range g.Moves {
+		switch {
+		case !mb.Ply:
+			tokens = append(tokens, strconv.Itoa(number)+".")
+		case i == 0:
+			tokens = append(tokens, strconv.Itoa(number)+"...")
+		}
+		tokens = append(tokens, gw.Notation.Format(p, mb))
+		if mb.Ply {
+			number++
+		}
+		p = UpdatePosition(p, mb)
+	}
+	tokens = append(tokens, result)
+
+	line := 0
+	for _, t := range tokens {
+		if line > 0 && line+1+len(t) > maxLine {
+			gw.w.WriteString("\n")
+			line = 0
+		}
+		if line > 0 {
+			gw.w.WriteString(" ")
+			line++
+		}
+		gw.w.WriteString(t)
+		line += len(t)
+	}
+	gw.w.WriteString("\n\n")
+	return gw.w.Flush()
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GameReader reads games from an io.Reader.
+type GameReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewGameReader(r io.Reader) *GameReader {
+	return &GameReader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next game. It returns io.EOF when there are no more.
+func (gr *GameReader) Read() (*Game, error) {
+	g := &Game{Tags: map[string]string{}}
+	var moves []field
+	inMoves := false
+	for {
+		text, err := gr.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if text == "" && err == io.EOF {
+			if !inMoves && len(g.Tags) == 0 {
+				return nil, io.EOF
+			}
+			return nil, &RecordError{gr.line, ErrBadRecord}
+		}
+		gr.line++
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "":
+		case !inMoves && strings.HasPrefix(text, "["):
+			if err := parseTag(g, text); err != nil {
+				return nil, &RecordError{gr.line, err}
+			}
+		default:
+			inMoves = true
+			for _, f := range strings.Fields(text) {
+				moves = append(moves, field{f, gr.line})
+			}
+			if n := len(moves); isResult(moves[n-1].text) && (n == 1 || !isWorker(moves[n-2].text)) {
+				if err := gr.play(g, moves[:n-1]); err != nil {
+					return nil, err
+				}
+				return g, nil
+			}
+		}
+	}
+}
+
+// parseTag reads a line of the form [Key "Value"] into g.
+func parseTag(g *Game, text string) error {
+	if !strings.HasSuffix(text, "]") {
+		return ErrBadRecord
+	}
+	key, value, ok := strings.Cut(text[1:len(text)-1], " ")
+	if !ok || key == "" {
+		return ErrBadRecord
+	}
+	value, err := strconv.Unquote(value)
+	if err != nil {
+		return ErrBadRecord
+	}
+	g.Tags[key] = value
+	return nil
+}
+
+func isResult(s string) bool {
+	return s == WhiteWins || s == BlackWins || s == Unfinished
+}
+
+// isWorker matches a worker's letter. A result after one is really the
+// squares of a winning move with its build left off, such as A 0-1.
+func isWorker(s string) bool {
+	return s == "A" || s == "B" || s == "X" || s == "Y"
+}
+
+// field is a word of the moves, and the line it is on.
+type field struct {
+	text string
+	line int
+}
+
+// play sets up g's start position from its tags, then plays the moves.
+func (gr *GameReader) play(g *Game, fields []field) error {
+	g.Start = NewGame()
+	if s, ok := g.Tags["Position"]; ok {
+		p, err := NewPosition(s)
+		if err != nil {
+			return &RecordError{gr.line, err}
+		}
+		g.Start = p
+	}
+	p := g.Start
+	for len(fields) > 0 {
+		if isMoveNumber(fields[0].text) {
+			fields = fields[1:]
+			continue
+		}
+		n := moveFields(p)
+		if n > len(fields) {
+			n = len(fields)
+		}
+		var text []string
+		for _, f := range fields[:n] {
+			text = append(text, f.text)
+		}
+		mb, used, err := parseMoveFields(p, text)
+		if err != nil {
+			err = &MoveError{Input: strings.Join(text, " "), Err: err}
+			return &RecordError{fields[0].line, err}
+		}
+		g.Moves = append(g.Moves, mb)
+		p = UpdatePosition(p, mb)
+		fields = fields[used:]
+	}
+	return nil
+}
+
+// isMoveNumber matches "12." and "12...".
+func isMoveNumber(s string) bool {
+	digits := strings.TrimRight(s, ".")
+	if digits == s || digits == "" {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
+
+// ReadGames reads every game from r.
+func ReadGames(r io.Reader) ([]*Game, error) {
+	gr := NewGameReader(r)
+	var games []*Game
+	for {
+		g, err := gr.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, g)
+	}
+}
@@ -0,0 +1,160 @@
+package Santorini
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// playOut plays g to the end, or for at most limit moves, with shallow
+// searches.
+func playOut(t *testing.T, g *Game, limit int) {
+	t.Helper()
+	for i := 0; i < limit && g.Result() == Unfinished; i++ {
+		p := g.Position()
+		if r := p.Result(); r.Reason == Climbed {
+			if err := g.Play(r.Move); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := g.Play(Search(p, 1).Move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGameRecordRoundTrip(t *testing.T) {
+	first := NewGameRecord()
+	first.Tags["White"] = "Ann"
+	first.Tags["Black"] = `Bob "the builder"`
+	first.Tags["Date"] = "2024.05.01"
+	first.Tags["Opening"] = "Corners"
+	playOut(t, first, 200)
+	if first.Result() == Unfinished {
+		t.Fatalf("expected the game to finish")
+	}
+
+	start, e := NewPosition("|0400300002001303040111124|05080018|b|7|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	second := &Game{Tags: map[string]string{"Event": "Endgame study"}, Start: start}
+	playOut(t, second, 3)
+
+	var buf bytes.Buffer
+	w := NewGameWriter(&buf)
+	if err := w.Write(first); err != nil {
+		t.Fatal(err)
+	}
+	w.Notation = Coordinates
+	if err := w.Write(second); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "[Position \"|0400300002001303040111124|05080018|b|7|\"]") ||
+		!strings.Contains(text, "\n7... ") {
+		t.Fatalf("expected the second game to start from its position, got\n%v", text)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) > maxLine {
+			t.Fatalf("line longer than %v: %q", maxLine, line)
+		}
+	}
+
+	games, err := ReadGames(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %v", len(games))
+	}
+	for i, want := range []*Game{first, second} {
+		got := games[i]
+		if !reflect.DeepEqual(got.Moves, want.Moves) || got.Start.String() != want.Start.String() {
+			t.Fatalf("game %v changed in a round trip:\n%v", i, text)
+		}
+		if got.Result() != want.Result() || got.Tags["Result"] != want.Result() {
+			t.Fatalf("game %v: expected result %v, got %v", i, want.Result(), got.Tags["Result"])
+		}
+	}
+	if games[0].Tags["Black"] != `Bob "the builder"` || games[0].Tags["Opening"] != "Corners" {
+		t.Fatalf("tags changed in a round trip: %v", games[0].Tags)
+	}
+}
+
+func TestReadGameExample(t *testing.T) {
+	// The example from the Game documentation.
+	example := `[White "Ann"]
+[Black "Bob"]
+[Date "2024.05.01"]
+[Result "*"]
+
+1. A 6 B 12 X 8 Y 16 2. A 6-7^2 X 8-9^3 3. B 12-13^14 *
+`
+	g, err := NewGameReader(strings.NewReader(example)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Moves) != 5 || g.Result() != Unfinished {
+		t.Fatalf("expected 5 moves of an unfinished game, got %v", g.Moves)
+	}
+	if got := g.Position().String(); got != "|0011000000000010000000000|07130916|b|3|" {
+		t.Fatalf("unexpected final position %v", got)
+	}
+}
+
+func TestReadWinningMoveWithoutBuild(t *testing.T) {
+	// A 0-1 climbs to the third level, so it needs no build, and looks
+	// like Black's result when it ends a line.
+	for _, moves := range []string{
+		"1. A 0-1\n1-0\n",
+		"1. A 0-1 1-0\n",
+	} {
+		text := "[Position \"|2300000000000000000000000|00120816|w|1|\"]\n\n" + moves
+		g, err := NewGameReader(strings.NewReader(text)).Read()
+		if err != nil {
+			t.Fatalf("%q: %v", moves, err)
+		}
+		if len(g.Moves) != 1 || g.Result() != WhiteWins {
+			t.Fatalf("%q: expected White's winning move, got %v and %v", moves, g.Moves, g.Result())
+		}
+	}
+}
+
+func TestReadGameErrors(t *testing.T) {
+	for text, want := range map[string]error{
+		"[White \"Ann\"]\n\n1. A 6 B 12 X 8 Y 16\n2. A 6-8^2 *\n": ErrIllegalMove,
+		"[White Ann]\n\n*\n":               ErrBadRecord,
+		"[White \"Ann\"]\n\n1. A 6 B 12\n": ErrBadRecord,
+		"[Position \"|00|\"]\n\n*\n":       ErrBadLength,
+	} {
+		_, err := ReadGames(strings.NewReader(text))
+		var re *RecordError
+		if !errors.As(err, &re) || !errors.Is(err, want) {
+			t.Fatalf("%q: expected %v, got %v", text, want, err)
+		}
+		if want == ErrIllegalMove && re.Line != 4 {
+			t.Fatalf("expected the illegal move on line 4, got %v", re.Line)
+		}
+	}
+}
+
+func TestGamePlay(t *testing.T) {
+	g := NewGameRecord()
+	if err := g.Play(MoveBuild{occupancy[3], occupancy[4], false, false}); err != ErrIllegalMove {
+		t.Fatalf("expected a move before placement to be illegal, got %v", err)
+	}
+	if g.Ended().Over() {
+		t.Fatalf("expected a new game not to have ended")
+	}
+	playOut(t, g, 200)
+	if !g.Ended().Over() {
+		t.Fatalf("expected the game to have ended, got %v", g.Ended())
+	}
+	if err := g.Play(g.Moves[len(g.Moves)-1]); err != ErrIllegalMove {
+		t.Fatalf("expected no moves after the end of the game, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package Santorini
+
+import (
+	"fmt"
+)
+
+// Reason says why a game is over, if it is.
+type Reason int
+
+const (
+	Ongoing Reason = iota // Nobody has won yet.
+	Climbed               // A worker moved up from level 2 onto level 3.
+	NoMoves               // The side to move has no move and build with either worker.
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Ongoing:
+		return "ongoing"
+	case Climbed:
+		return "climbed to level 3"
+	case NoMoves:
+		return "no legal move"
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// Result is the rules' verdict on a game.
+type Result struct {
+	Reason Reason
+	Winner bool      // Ply of the winner, false for White. Only meaningful once the game is over.
+	Move   MoveBuild // The winning move, if there is one.
+}
+
+// Over reports whether somebody has won.
+func (r Result) Over() bool {
+	return r.Reason != Ongoing
+}
+
+func sideName(ply bool) string {
+	if ply {
+		return "Black"
+	}
+	return "White"
+}
+
+// String explains the result in words, for game logs and the UI.
+func (r Result) String() string {
+	switch r.Reason {
+	case Climbed:
+		return fmt.Sprintf("%v wins by moving up to level 3 on square %v",
+			sideName(r.Winner), square(r.Move.Move))
+	case NoMoves:
+		return fmt.Sprintf("%v wins because %v has no legal move",
+			sideName(r.Winner), sideName(!r.Winner))
+	}
+	return "game in progress"
+}
+
+// Result decides p for the side to move. If they can move up onto level 3
+// they win, and Move is the climb. If neither of their workers can move and
+// then build, they lose. One stuck worker doesn't lose while the other can
+// still play, and the opponent being stuck doesn't matter until it is their
+// turn. Anything else is Ongoing.
+func (p Position) Result() Result {
+	moves := LegalBuildMoves(p)
+	if len(moves) == 0 {
+		return Result{Reason: NoMoves, Winner: !p.Ply}
+	}
+	for _, mb := range moves {
+		if winningMove(p, mb) {
+			return Result{Reason: Climbed, Winner: p.Ply, Move: mb}
+		}
+	}
+	return Result{Reason: Ongoing}
+}
+
+// ResultAfter is the result of playing mb from p: a win for the mover if mb
+// climbs or leaves the opponent without a move, and Ongoing otherwise.
+func (p Position) ResultAfter(mb MoveBuild) Result {
+	if winningMove(p, mb) {
+		return Result{Reason: Climbed, Winner: p.Ply, Move: mb}
+	}
+	if len(LegalBuildMoves(UpdatePosition(p, mb))) == 0 {
+		return Result{Reason: NoMoves, Winner: p.Ply, Move: mb}
+	}
+	return Result{Reason: Ongoing}
+}
@@ -0,0 +1,75 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		position string
+		want     Result
+		outcome  rune
+	}{
+		{"White can climb", "|2330000000000000000000000|00202224|w|",
+			Result{Climbed, false, MoveBuild{occupancy[1], occupancy[0], false, false}}, 'W'},
+		{"Black can climb", "|0000000000000000000002300|00042123|b|",
+			Result{Climbed, true, MoveBuild{occupancy[22], occupancy[16], true, false}}, 'B'},
+		{"White is stuck", "|0404044044000000000000000|00042024|w|",
+			Result{Reason: NoMoves, Winner: true}, 'B'},
+		{"one White worker is stuck", "|0404044000000000000000000|00042024|w|",
+			Result{Reason: Ongoing}, '?'},
+		{"Black is stuck, but it's White's turn", "|0000000000000004404404040|00222024|w|",
+			Result{Reason: Ongoing}, '?'},
+		{"nobody can climb", "|0400300002001303040111124|05080018|w|",
+			Result{Reason: Ongoing}, '?'},
+	}
+	for _, tc := range tests {
+		position, e := NewPosition(tc.position)
+		if e != nil {
+			t.Fatalf("%v: %v", tc.name, e)
+		}
+		got := position.Result()
+		if got != tc.want {
+			t.Fatalf("%v: expected %+v, got %+v", tc.name, tc.want, got)
+		}
+		if o := position.Outcome(); o != tc.outcome {
+			t.Fatalf("%v: expected outcome %q, got %q", tc.name, tc.outcome, o)
+		}
+	}
+}
+
+func TestResultAfter(t *testing.T) {
+	// White's worker on 22 steps to 17 and builds 21 up to level 2, out of
+	// reach of Black, which leaves both of its workers with nowhere to go.
+	position, e := NewPosition("|0000000000000004404401040|00222024|w|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	trap := MoveBuild{occupancy[17], occupancy[21], false, true}
+	want := Result{NoMoves, false, trap}
+	if got := position.ResultAfter(trap); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got, want := want.String(), "White wins because Black has no legal move"; got != want {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	// Building somewhere else lets Black carry on.
+	quiet := MoveBuild{occupancy[17], occupancy[12], false, true}
+	if got := position.ResultAfter(quiet); got.Over() {
+		t.Fatalf("expected the game to go on, got %v", got)
+	}
+
+	position, e = NewPosition("|2330000000000000000000000|00202224|w|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	climb := MoveBuild{occupancy[1], occupancy[2], false, false}
+	got := position.ResultAfter(climb)
+	if got.Reason != Climbed || got.Winner || got.Move != climb {
+		t.Fatalf("expected White to win by climbing, got %+v", got)
+	}
+	if got, want := got.String(), "White wins by moving up to level 3 on square 1"; got != want {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+}
@@ -1,10 +1,11 @@
 package Santorini
 
 import (
+	"errors"
 	"fmt"
   "sort"
 	"strconv"
-	"strings"
+	"time"
 )
 
 type Position struct {
@@ -18,6 +19,9 @@ type Position struct {
 	Y              int32 // Second Red Piece
 	Representation string
 	Ply            bool // False for White, which moves first, True for Black.
+	Placing        bool // True until both sides have placed their workers.
+	MoveNumber     int  // Counts up from 1 after each of Black's moves, 0 if not tracked.
+	Hash           uint64 // Zobrist hash, kept up to date by UpdatePosition.
 }
 
 type MoveBuild struct {
@@ -66,38 +70,6 @@ var occupancy []int32 = []int32{
 	1 << 24,
 }
 
-// map where keys are positionally encoded pieces
-// like 0b0000001000000000 for a piece in square 6
-// and values are list of positially encoded possible
-// king moves if it were a chessboard.
-var kingMoves = map[int32][]int32{
-	occupancy[0]:  []int32{1 << 1, 1 << 5, 1 << 6},
-	occupancy[1]:  []int32{1 << 0, 1 << 2, 1 << 5, 1 << 6, 1 << 7},
-	occupancy[2]:  []int32{1 << 1, 1 << 3, 1 << 6, 1 << 7, 1 << 8},
-	occupancy[3]:  []int32{1 << 2, 1 << 4, 1 << 7, 1 << 8, 1 << 9},
-	occupancy[4]:  []int32{1 << 3, 1 << 8, 1 << 9},
-	occupancy[5]:  []int32{1 << 0, 1 << 1, 1 << 6, 1 << 10, 1 << 11},
-	occupancy[6]:  []int32{1 << 0, 1 << 1, 1 << 2, 1 << 5, 1 << 7, 1 << 10, 1 << 11, 1 << 12},
-	occupancy[7]:  []int32{1 << 1, 1 << 2, 1 << 3, 1 << 6, 1 << 8, 1 << 11, 1 << 12, 1 << 13},
-	occupancy[8]:  []int32{1 << 2, 1 << 3, 1 << 4, 1 << 7, 1 << 9, 1 << 12, 1 << 13, 1 << 14},
-	occupancy[9]:  []int32{1 << 3, 1 << 4, 1 << 8, 1 << 13, 1 << 14},
-	occupancy[10]: []int32{1 << 5, 1 << 6, 1 << 11, 1 << 15, 1 << 16},
-	occupancy[11]: []int32{1 << 5, 1 << 6, 1 << 7, 1 << 10, 1 << 12, 1 << 15, 1 << 16, 1 << 17},
-	occupancy[12]: []int32{1 << 6, 1 << 7, 1 << 8, 1 << 11, 1 << 13, 1 << 16, 1 << 17, 1 << 18},
-	occupancy[13]: []int32{1 << 7, 1 << 8, 1 << 9, 1 << 12, 1 << 14, 1 << 17, 1 << 18, 1 << 19},
-	occupancy[14]: []int32{1 << 8, 1 << 9, 1 << 13, 1 << 18, 1 << 19},
-	occupancy[15]: []int32{1 << 10, 1 << 11, 1 << 16, 1 << 20, 1 << 21},
-	occupancy[16]: []int32{1 << 10, 1 << 11, 1 << 12, 1 << 15, 1 << 17, 1 << 20, 1 << 21, 1 << 22},
-	occupancy[17]: []int32{1 << 11, 1 << 12, 1 << 13, 1 << 16, 1 << 18, 1 << 21, 1 << 22, 1 << 23},
-	occupancy[18]: []int32{1 << 12, 1 << 13, 1 << 14, 1 << 17, 1 << 19, 1 << 22, 1 << 23, 1 << 24},
-	occupancy[19]: []int32{1 << 13, 1 << 14, 1 << 18, 1 << 23, 1 << 24},
-	occupancy[20]: []int32{1 << 15, 1 << 16, 1 << 21},
-	occupancy[21]: []int32{1 << 15, 1 << 16, 1 << 17, 1 << 20, 1 << 22},
-	occupancy[22]: []int32{1 << 16, 1 << 17, 1 << 18, 1 << 21, 1 << 23},
-	occupancy[23]: []int32{1 << 17, 1 << 18, 1 << 19, 1 << 22, 1 << 24},
-	occupancy[24]: []int32{1 << 18, 1 << 19, 1 << 23},
-}
-
 func render(p Position) string {
 	var b1 int32 = p.B1
 	var b2 int32 = p.B2
@@ -115,7 +87,8 @@ func render(p Position) string {
 
 	out += "|"
 
-	var piecePlacement [4]int
+	// Workers that haven't been placed yet stay at -1.
+	piecePlacement := [4]int{-1, -1, -1, -1}
 	for i := 0; i < 25; i++ {
 		tile := "0"
 		if b1%2 == 1 {
@@ -156,13 +129,26 @@ func render(p Position) string {
 		continue
 	}
 
-	pieces := fmt.Sprintf("%02d", piecePlacement)
-	pieces = strings.Replace(pieces, "[", "", -1)
-	pieces = strings.Replace(pieces, "]", "", -1)
-	pieces = strings.Replace(pieces, " ", "", -1)
+	pieces := ""
+	for _, sq := range piecePlacement {
+		if sq < 0 {
+			pieces += "--"
+			continue
+		}
+		pieces += fmt.Sprintf("%02d", sq)
+	}
 	out += "|" + pieces
 	out += "|"
 
+	if p.Ply {
+		out += "b|"
+	} else {
+		out += "w|"
+	}
+	if p.MoveNumber > 0 {
+		out += strconv.Itoa(p.MoveNumber) + "|"
+	}
+
 	return out
 
 }
@@ -174,6 +160,34 @@ func (p Position) String() string {
 	// return p.Representation
 }
 
+// Errors NewPosition can report, wrapped in a *PositionError so callers
+// can find out where the problem is. Use errors.Is to tell them apart.
+var (
+	ErrBadLength    = errors.New("position has the wrong length")
+	ErrBadSeparator = errors.New("expected a | separator")
+	ErrBadHeight    = errors.New("height must be a digit from 0 to 4")
+	ErrBadSquare    = errors.New("worker square must be from 00 to 24, or -- before placement")
+	ErrOverlap      = errors.New("two workers on one square")
+	ErrWorkerOnDome = errors.New("worker on a dome")
+	ErrSideToMove   = errors.New("side to move must be w or b, and match the placement phase")
+	ErrMoveNumber   = errors.New("move number must be a positive integer")
+)
+
+// PositionError is the error NewPosition returns for a string it can't use.
+type PositionError struct {
+	Input  string // The string passed to NewPosition.
+	Offset int    // Index into Input where the problem is.
+	Err    error  // One of the Err values above.
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("santorini: bad position %q at offset %d: %v", e.Input, e.Offset, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
 // Stringified positions take the form
 // |0400300002001303040111124|08050018|
 // 25 ints from 0 to 4 representing the heights
@@ -182,13 +196,33 @@ func (p Position) String() string {
 // Then 4 ints (of format 00), representing position of
 // White's first and second piece, then
 // Black's first and second piece.
-// Note that white and black's pieces are interchangeable.
-// We should probably require the position go from
-// low to high as another integrity check
+// Note that white and black's pieces are interchangeable,
+// so each side's pieces are put in order.
+//
+// Workers that haven't been placed yet are written --, so the
+// empty board at the start of a game is
+// |0000000000000000000000000|--------|
+// White places first, so Black can only have workers on the
+// board if White does, and a side places both at once.
+//
+// The side to move comes next, w or b, and can be followed by
+// the move number:
+// |0400300002001303040111124|08050018|b|
+// |0400300002001303040111124|08050018|b|12|
+// String always writes the side to move. Without it, we guess
+// from the parity of the odd height tiles, which is only right
+// until a dome goes on a level 3 or a move keeps the parity.
 func NewPosition(s string) (Position, error) {
-	// Position
-	if len(s) != 36 {
-		panic("string integrity check fail, position incorrect length")
+	fail := func(offset int, err error) (Position, error) {
+		return Position{}, &PositionError{s, offset, err}
+	}
+	if len(s) < 36 {
+		return fail(len(s), ErrBadLength)
+	}
+	for _, i := range []int{0, 26, 35} {
+		if s[i] != '|' {
+			return fail(i, ErrBadSeparator)
+		}
 	}
 	p := Position{}
 
@@ -199,6 +233,7 @@ func NewPosition(s string) (Position, error) {
 		var mask int32
 		mask = 1 << (i - 1)
 		switch height {
+		case '0':
 		case '1':
 			p.B1 |= mask
 			parity = !parity // flip parity bit for odd tiles
@@ -215,57 +250,149 @@ func NewPosition(s string) (Position, error) {
 			p.B2 |= mask
 			p.B3 |= mask
 			p.B4 |= mask
+		default:
+			return fail(i, ErrBadHeight)
 		}
+	}
 
+	// White's first and second, then Black's first and second.
+	// -1 for a worker that hasn't been placed.
+	var squares [4]int
+	for w := range squares {
+		offset := 27 + 2*w
+		field := s[offset : offset+2]
+		if field == "--" {
+			squares[w] = -1
+			continue
+		}
+		if field[0] < '0' || field[0] > '9' || field[1] < '0' || field[1] > '9' {
+			return fail(offset, ErrBadSquare)
+		}
+		sq, _ := strconv.Atoi(field)
+		if sq > 24 {
+			return fail(offset, ErrBadSquare)
+		}
+		if occupancy[sq]&p.B4 != 0 {
+			return fail(offset, ErrWorkerOnDome)
+		}
+		for other := 0; other < w; other++ {
+			if squares[other] == sq {
+				return fail(offset, ErrOverlap)
+			}
+		}
+		squares[w] = sq
+	}
+	// Both of a side's workers go down together, White's first.
+	whitePlaced := squares[0] >= 0
+	blackPlaced := squares[2] >= 0
+	if (squares[1] >= 0) != whitePlaced {
+		return fail(29, ErrBadSquare)
+	}
+	if (squares[3] >= 0) != blackPlaced {
+		return fail(33, ErrBadSquare)
+	}
+	if blackPlaced && !whitePlaced {
+		return fail(31, ErrBadSquare)
 	}
-	var err error
-	whiteOne, err := strconv.Atoi(s[27:29])
-	whiteTwo, err := strconv.Atoi(s[29:31])
-	blackOne, err := strconv.Atoi(s[31:33])
-	blackTwo, err := strconv.Atoi(s[33:35])
 
 	// swap occupancies to keep the lowest numbered piece first
-	if whiteOne > whiteTwo {
-		whiteOne, whiteTwo = whiteTwo, whiteOne
+	if squares[0] > squares[1] {
+		squares[0], squares[1] = squares[1], squares[0]
 	}
-	if blackOne > blackTwo {
-		blackOne, blackTwo = blackTwo, blackOne
+	if squares[2] > squares[3] {
+		squares[2], squares[3] = squares[3], squares[2]
 	}
-
-	if err != nil {
-		panic("string integrity check fail, can't decode piece position")
+	if whitePlaced {
+		p.A = occupancy[squares[0]]
+		p.B = occupancy[squares[1]]
+	}
+	if blackPlaced {
+		p.X = occupancy[squares[2]]
+		p.Y = occupancy[squares[3]]
 	}
-	p.A = occupancy[whiteOne]
-	p.B = occupancy[whiteTwo]
-	p.X = occupancy[blackOne]
-	p.Y = occupancy[blackTwo]
+
 	p.Ply = parity
+	if !blackPlaced {
+		// Still placing, and whoever hasn't placed yet is to move.
+		p.Placing = true
+		p.Ply = whitePlaced
+	}
+
+	// Explicit side to move and move number.
+	if len(s) > 36 {
+		if len(s) < 38 {
+			return fail(len(s), ErrBadLength)
+		}
+		switch {
+		case s[36] == 'w' && !(p.Placing && p.Ply):
+			p.Ply = false
+		case s[36] == 'b' && !(p.Placing && !p.Ply):
+			p.Ply = true
+		default:
+			return fail(36, ErrSideToMove)
+		}
+		if s[37] != '|' {
+			return fail(37, ErrBadSeparator)
+		}
+		if rest := s[38:]; rest != "" {
+			if rest[len(rest)-1] != '|' {
+				return fail(len(s)-1, ErrBadSeparator)
+			}
+			digits := rest[:len(rest)-1]
+			for i := 0; i < len(digits); i++ {
+				if digits[i] < '0' || digits[i] > '9' {
+					return fail(38+i, ErrMoveNumber)
+				}
+			}
+			n, err := strconv.Atoi(digits)
+			if err != nil || n < 1 {
+				return fail(38, ErrMoveNumber)
+			}
+			p.MoveNumber = n
+		}
+	}
+	p.Hash = p.ComputeHash()
 
 	return p, nil
 }
 
 func UpdatePosition(p Position, b MoveBuild) Position {
-	// update strings here too
+	if p.Placing {
+		return placeWorkers(p, b)
+	}
+	// update the hash here too
+	var from int32
 	switch {
 	case !b.Ply && !b.Piece:
-		p.A = b.Move
+		from, p.A = p.A, b.Move
 	case !b.Ply && b.Piece:
-		p.B = b.Move
+		from, p.B = p.B, b.Move
 	case b.Ply && !b.Piece:
-		p.X = b.Move
+		from, p.X = p.X, b.Move
 	case b.Ply && b.Piece:
-		p.Y = b.Move
+		from, p.Y = p.Y, b.Move
+	}
+	side := 0
+	if b.Ply {
+		side = 1
 	}
+	p.Hash ^= zobristWorker[side][square(from)] ^ zobristWorker[side][square(b.Move)]
+
 	// logic to build
 	switch {
+	case b.Build == 0:
 	case p.B1&b.Build == 0:
 		p.B1 |= b.Build
+		p.Hash ^= zobristHeight[0][square(b.Build)]
 	case p.B2&b.Build == 0:
 		p.B2 |= b.Build
+		p.Hash ^= zobristHeight[1][square(b.Build)]
 	case p.B3&b.Build == 0:
 		p.B3 |= b.Build
+		p.Hash ^= zobristHeight[2][square(b.Build)]
 	case p.B4&b.Build == 0:
 		p.B4 |= b.Build
+		p.Hash ^= zobristHeight[3][square(b.Build)]
 	}
 
 	// make sure
@@ -277,108 +404,88 @@ func UpdatePosition(p Position, b MoveBuild) Position {
 	}
   //Flip whose turn it is
   p.Ply = !p.Ply
+	p.Hash ^= zobristBlack
+	if b.Ply && p.MoveNumber > 0 {
+		p.MoveNumber++
+	}
 	return p
 }
 
-func legalMoves2(p Position, piece int32) []int32 {
-	mask := ^(p.A | p.B | p.X | p.Y | p.B4)
-	// If you're not at least 1 high, can't go to 2 or 3
-	if piece&p.B1 == 0 {
-		mask = mask &^ (p.B2 | p.B3)
+// placeWorkers puts the side to move's two workers on the squares in b.Move.
+func placeWorkers(p Position, b MoveBuild) Position {
+	first := b.Move & -b.Move
+	second := b.Move &^ first
+	side := 0
+	if b.Ply {
+		side = 1
+		p.X, p.Y = first, second
+		// Black places last, so the game proper starts now.
+		p.Placing = false
+	} else {
+		p.A, p.B = first, second
 	}
-	// If you're not at least 2 high, can't go to 3
-	if piece&p.B2 == 0 {
-		mask = mask &^ (p.B3)
-	}
-	//fmt.Printf("MASK %v\n", mask)
-	var ret []int32
-
-	km, ok := kingMoves[piece]
-	if !ok {
-		panic("Map error")
+	p.Hash ^= zobristWorker[side][square(first)] ^ zobristWorker[side][square(second)]
+	p.Ply = !p.Ply
+	p.Hash ^= zobristBlack
+	if b.Ply && p.MoveNumber > 0 {
+		p.MoveNumber++
 	}
+	return p
+}
 
-	// this will be at most 8 ops, often fewer.
-	for _, move := range km {
-		if test := move & mask; test != 0 {
-			ret = append(ret, test)
-		}
-	}
-	return ret
+// legalMoves2 lists the squares piece can move to.
+func legalMoves2(p Position, piece int32) []int32 {
+	return maskBits(moveTargets(p, piece))
 }
 
 // legalBuilds assumes the piece don't move.
 // so applying to it any but one of the 4 actual pieces
 // returns nonsense.
 func legalBuilds(p Position, piece int32) []int32 {
-	// Assume you can build anywhere
-	// minus where any of the pieces are, or on a 4 tile.
-	//var mask int32 = 1<<25
-	mask := ((1 << 25) - 1) &^ (p.A | p.B | p.X | p.Y | p.B4)
-	//fmt.Printf("At Position %v, got mask \n%v\n", p.String(), mask)
-
-	var ret []int32
+	// Anywhere next to the piece, minus where any of the pieces are,
+	// or on a 4 tile.
+	return maskBits(adjacent[square(piece)] &^ uint32(p.A|p.B|p.X|p.Y|p.B4))
+}
 
-	km, ok := kingMoves[piece]
-	if !ok {
-		panic("Map error")
-	}
+// LegalBuildMoves lists every move and build, or placement, open to the side
+// to move. Search code should use AppendMoves with a reused buffer instead.
+func LegalBuildMoves(p Position) []MoveBuild {
+	return AppendMoves(nil, p)
+}
 
-	// this will be at most 8 ops, often fewer.
-	for _, move := range km {
-		if test := move & mask; test != 0 {
-			ret = append(ret, test)
-		}
+// worker returns the bit of the first or second worker of a side.
+func (p Position) worker(ply bool, second bool) int32 {
+	switch {
+	case !ply && !second:
+		return p.A
+	case !ply && second:
+		return p.B
+	case ply && !second:
+		return p.X
 	}
-	return ret
+	return p.Y
 }
 
-func legalBuildMoves(p Position) []MoveBuild {
-	var ret []MoveBuild
-	// If it's white's turn to move
-	var piece1, piece2 int32
-	if !p.Ply {
-		piece1 = p.A
-		piece2 = p.B
-	} else {
-		piece1 = p.X
-		piece2 = p.Y
+// winningMove reports whether playing mb from p wins the game outright,
+// which happens when the worker moves up from level 2 onto level 3.
+// Moving from one level 3 tile to another doesn't win, and placing a
+// worker never wins.
+func winningMove(p Position, mb MoveBuild) bool {
+	if p.Placing {
+		return false
 	}
+	from := p.worker(mb.Ply, mb.Piece)
+	onTwo := from&p.B2 != 0 && from&p.B3 == 0
+	toThree := mb.Move&p.B3 != 0 && mb.Move&p.B4 == 0
+	return onTwo && toThree
+}
 
-	// Don't forget to update the position before you try to get the builds.
-	for _, m := range legalMoves2(p, piece1) {
-		testPiece := p //here it is
-		if !p.Ply {
-			testPiece.A = m
-		} else {
-			testPiece.X = m
-		}
-    legalbuilds := legalBuilds(testPiece, m)
-    // Don't consider a move if there are no builds from it.
-    if len(legalbuilds) == 0 {
-      continue
-    }
-		for _, b := range legalBuilds(testPiece, m) {
-			ret = append(ret, MoveBuild{m, b, p.Ply, false})
-		}
-	}
-	for _, m := range legalMoves2(p, piece2) {
-		testPiece := p
-		if !p.Ply {
-			testPiece.B = m
-		} else {
-			testPiece.Y = m
-		}
-    // Don't consider a move if there are no builds from it.
-    legalbuilds := legalBuilds(testPiece, m)
-    if len(legalbuilds) == 0 {
-      continue
-    }
-		for _, b := range legalBuilds(testPiece, m) {
-			ret = append(ret, MoveBuild{m, b, p.Ply, true})
-		}
-	}
-	return ret
+// NewGame is the empty board that every game starts from, with White to
+// place their workers.
+func NewGame() Position {
+	p := Position{Placing: true, MoveNumber: 1}
+	p.Hash = p.ComputeHash()
+	return p
 }
 
 /*
@@ -387,10 +494,10 @@ func legalBuildMoves(p Position) []MoveBuild {
 
 func (p Position) Children()[]GameNode{
   var pp []GameNode
-  for _, mb := range legalBuildMoves(p) {
+  for _, mb := range LegalBuildMoves(p) {
     updated := UpdatePosition(p, mb)
     // If any of the moves are a win, that's it. Return no children.
-    if (mb.Move & updated.B3) > 0 {
+    if winningMove(p, mb) {
       return nil
     }
     pp = append(pp, updated)
@@ -400,28 +507,16 @@ func (p Position) Children()[]GameNode{
 
 func (p Position) Outcome() rune {
   // if it's my turn, one of my pieces is on a 2, and can move to a 3, I win.
-  // Or, if either side can either not build or not move, I win.
-  // If I can't build or move, I lose.
-  lbm := legalBuildMoves(p)
-  if len(lbm) == 0 {
-    //panic("No legal moves")
-    if !p.Ply {
-      return 'B'
-    } else {
-      return 'W'
-    }
-  }
-
-  for _, move := range lbm{
-    if (move.Move & p.B3) > 0 {
-      if !p.Ply {
-        return 'W'
-      } else {
-        return 'B'
-      }
-    }
+  // If I can't move and build with either piece, I lose.
+  // See Result for the details.
+  r := p.Result()
+  switch {
+  case !r.Over():
+    return '?'
+  case r.Winner:
+    return 'B'
   }
-  return '?'
+  return 'W'
 }
 
 func (p Position) WhichPly() bool{
@@ -475,6 +570,16 @@ Find the first leafLimit bfs outcomes.
 */
 // 21020 00100 44344 00231 00010 |01081924| false ?
 func ShallowExploreNode(gn GameNode, leafLimit int) (map[string]rune, int, int){
+  m, shallowestDepth, currentGeneration, _ := ShallowExploreNodeStats(gn, leafLimit, nil)
+  return m, shallowestDepth, currentGeneration
+}
+
+// ShallowExploreNodeStats is ShallowExploreNode, that also says how much
+// work it did. tracer, if not nil, hears about every node explored, and gets
+// the stats every 1024 nodes.
+func ShallowExploreNodeStats(gn GameNode, leafLimit int, tracer Tracer) (map[string]rune, int, int, SearchStats){
+  start := time.Now()
+  var stats SearchStats
   depth := 0
   shallowestDepth := 100
   currentGeneration := 1
@@ -483,6 +588,9 @@ func ShallowExploreNode(gn GameNode, leafLimit int) (map[string]rune, int, int){
   exploreLimit := 10000
   explored := 0
   m := make(map[string]rune)
+  // Leaves we've already recorded, keyed by hash so we only build each
+  // leaf's string once.
+  seen := make(map[uint64]bool)
   var toExplore []GameNode
   toExplore = append(toExplore, gn)
 
@@ -490,12 +598,26 @@ func ShallowExploreNode(gn GameNode, leafLimit int) (map[string]rune, int, int){
      var pop GameNode
      explored++
      pop, toExplore = toExplore[0], toExplore[1:]
+     stats.Nodes++
+     stats.reach(depth)
+     if tracer != nil {
+       if explored % 1024 == 0 {
+         stats.Elapsed = time.Since(start)
+         tracer.Progress(stats)
+       }
+       tracer.Visit(pop, depth)
+     }
 
      // Now, get the deets on the head node.
      if o := pop.Outcome(); (o == 'W') || (o == 'B'){
       //fmt.Printf("\nHit a leaf %v, with outcome %v", pop.String(), string(o))
      //fmt.Printf("\n%v", n.String()+string(o))
-       m[pop.String()]=o
+       if k := nodeKey(pop); !seen[k] {
+         seen[k] = true
+         m[pop.String()]=o
+       } else {
+         stats.TTHits++
+       }
        if depth < shallowestDepth{
          shallowestDepth = depth
        }
@@ -511,9 +633,11 @@ func ShallowExploreNode(gn GameNode, leafLimit int) (map[string]rune, int, int){
      }
 
      // Otherwise, get the children and add them to the list to inspect
+     stats.Expanded++
      for _, child := range pop.Children(){
        toExplore = append(toExplore, child)
        nextGeneration++
+       stats.Children++
      }
 
      currentGeneration = currentGeneration - 1
@@ -527,14 +651,35 @@ func ShallowExploreNode(gn GameNode, leafLimit int) (map[string]rune, int, int){
     //fmt.Printf("\nEnding on current Gen %v", currentGeneration)
   //}
   // After search stuff
-  return m, shallowestDepth, currentGeneration
+  stats.Elapsed = time.Since(start)
+  if tracer != nil {
+    tracer.Progress(stats)
+  }
+  return m, shallowestDepth, currentGeneration, stats
 }
 
 /*
 Code to explore the game tree.
 */
 func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
+  m, _ := ExploreNodeStats(gn, leafLimit, nil)
+  return m
+}
+
+// ExploreNodeStats is ExploreNode, that also says how much work it did.
+// tracer, if not nil, hears about every node explored, and gets the stats
+// every 100000 nodes.
+func ExploreNodeStats(gn GameNode, leafLimit int, tracer Tracer) (map[string]rune, SearchStats){
+  start := time.Now()
+  var stats SearchStats
   m := make(map[string]rune)
+  // Memo of solved nodes keyed by hash. m holds the same results by string
+  // for the caller, but building strings on every lookup is too slow.
+  seen := make(map[uint64]rune)
+  record := func(n GameNode, key uint64, o rune) {
+    seen[key] = o
+    m[n.String()] = o
+  }
   // Don't forget, this is PLY depth. so White and black moves combined
   //  maxDepth := 6
 
@@ -542,9 +687,12 @@ func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
 
   var f func(n GameNode, d int)rune
   f = func(n GameNode, d int)rune{
-     if limit % 100000 == 0{
-       //fmt.Printf("%v\tsolved:%v\tshortest:%v\n",limit, len(m), shortest)
-       fmt.Printf("Limit: %v Depth %v \t State:%v|%v\n", limit, d, n.String(),string(n.Outcome()))
+     if tracer != nil {
+       if limit > 0 && limit % 100000 == 0{
+         stats.Elapsed = time.Since(start)
+         tracer.Progress(stats)
+       }
+       tracer.Visit(n, d)
      }
        // Leaf Limit
        if leafLimit > 0 {
@@ -556,23 +704,28 @@ func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
 
      // Did I see this state before? If so, stop exploring it and descendants and
      // return what I know about it.
-     if val,ok := m[n.String()]; ok{
+     key := nodeKey(n)
+     if val,ok := seen[key]; ok{
+       stats.TTHits++
        return val
      }
      limit++
+     stats.Nodes++
+     stats.reach(d)
 
 
     // Check my outcome and return it if I'm a leaf node
     if o := n.Outcome(); (o == 'W') || (o == 'B'){
       //fmt.Printf("\nHit a leaf %v, with outcome %v", n.String(), string(o))
     //fmt.Printf("\n%v", n.String()+string(o))
-      m[n.String()]=o
+      record(n, key, o)
       return o
     }
     // So I'm not a leaf node.
     childOutcomes := make(map[rune]int)
 
     children := n.Children()
+    stats.Expanded++
     if leafLimit == 0 {
 
         //fmt.Printf("About to consider my children, %v\n", len(n.Children()))
@@ -606,7 +759,7 @@ func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
       if i > 0 && (leafLimit == 0){
         continue
       }
-      fmt.Printf("Going to: %v %v %v\n", c.String(), c.WhichPly(), string(c.Outcome()))
+      stats.Children++
       // Recurse here
       outcome := f(c, d+1)
       //fmt.Printf("State:%v\n", c.String())
@@ -619,7 +772,10 @@ func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
       // pick because it's my turn and I can to win, assume I will.
       // Stop searching
       if (!n.WhichPly() && (outcome == 'W')) || (n.WhichPly() && (outcome == 'B')){
-        m[n.String()]=outcome
+        if i < len(children)-1 && leafLimit != 0 {
+          stats.Cutoffs++
+        }
+        record(n, key, outcome)
         return outcome
       }
 
@@ -669,10 +825,9 @@ func ExploreNode(gn GameNode, leafLimit int) map[string]rune{
   }
   //fmt.Printf("\nStart node:\n%v\n", gn.String())
   f(gn, 0)
-  //SUMMARY
-  //fmt.Printf("Final summary\n")
-  //for k, v := range m {
-    //fmt.Printf("\n%v %v", k, string(v) )
-  //}
-  return m
+  stats.Elapsed = time.Since(start)
+  if tracer != nil {
+    tracer.Progress(stats)
+  }
+  return m, stats
 }
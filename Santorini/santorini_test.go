@@ -1,6 +1,7 @@
 package Santorini
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	_ "sort"
@@ -9,19 +10,19 @@ import (
 
 func TestPositionToString(t *testing.T) {
 	testPosition1 := Position{
-		0x1FAB212, 0x182A212, 0x102A012, 0x1020002,
-		occupancy[11],
-		occupancy[12],
-		occupancy[16],
-		occupancy[17],
-		"",
-		false,
+		B1: 0x1FAB212, B2: 0x182A212, B3: 0x102A012, B4: 0x1020002,
+		A: occupancy[11],
+		B: occupancy[12],
+		X: occupancy[16],
+		Y: occupancy[17],
 	}
 
 	got := testPosition1.String()
 	want := "|0400300002001303040111124|11121617|"
 
-	if got != want {
+	// The original form, with the side to move added. It has a worker on a
+	// dome, which NewPosition won't read, so it can't be parsed instead.
+	if want := want + "w|"; got != want {
 		t.Errorf("got %q, wanted %q", got, want)
 	}
 }
@@ -33,11 +34,142 @@ func TestNewPosition(t *testing.T) {
 	}
 	got := position.String()
 	want := "|0400300002001303040111124|05080018|"
-	if got != want {
+	if want := parsedString(t, want); got != want {
 		t.Errorf("\ngot\t\t\t%q\n, wanted \t%q", got, want)
 	}
 }
 
+// parsedString reads s, in either form, and writes it back out, so that
+// tests written before positions had a side to move still hold.
+func parsedString(t *testing.T, s string) string {
+	t.Helper()
+	p, err := NewPosition(s)
+	if err != nil {
+		t.Fatalf("%q: %v", s, err)
+	}
+	return p.String()
+}
+
+func parsedStrings(t *testing.T, list []string) []string {
+	t.Helper()
+	var out []string
+	for _, s := range list {
+		out = append(out, parsedString(t, s))
+	}
+	return out
+}
+
+func TestPositionToStringSideToMove(t *testing.T) {
+	position := Position{
+		B1: 0x1FAB212, B2: 0x182A212, B3: 0x102A012, B4: 0x1020002,
+		A: occupancy[11], B: occupancy[12], X: occupancy[16], Y: occupancy[17],
+	}
+	if got, want := position.String(), "|0400300002001303040111124|11121617|w|"; got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	position.Ply, position.MoveNumber = true, 7
+	if got, want := position.String(), "|0400300002001303040111124|11121617|b|7|"; got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	// Legacy strings come back with the side to move that parity gives.
+	for legacy, want := range map[string]string{
+		"|0400300002001303040111124|08050018|": "|0400300002001303040111124|05080018|w|",
+		"|0410300002001303040111124|06080018|": "|0410300002001303040111124|06080018|b|",
+	} {
+		if got := parsedString(t, legacy); got != want {
+			t.Errorf("%q: got %q, wanted %q", legacy, got, want)
+		}
+		if got := parsedString(t, want); got != want {
+			t.Errorf("%q didn't round trip: got %q", want, got)
+		}
+	}
+}
+
+func TestNewPositionErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		err    error
+		offset int
+	}{
+		{"|0400300002001303040111124|0805001|", ErrBadLength, 35},
+		{"", ErrBadLength, 0},
+		{"|0400300002001303040111124/08050018|", ErrBadSeparator, 26},
+		{"|0400300002001303040111125|08050018|", ErrBadHeight, 25},
+		{"|04003000x2001303040111124|08050018|", ErrBadHeight, 9},
+		{"|0400300002001303040111124|x8050018|", ErrBadSquare, 27},
+		{"|0400300002001303040111124|-8050018|", ErrBadSquare, 27},
+		{"|0400300002001303040111124|08050025|", ErrBadSquare, 33},
+		{"|0400300002001303040111124|99050018|", ErrBadSquare, 27},
+		{"|0400300002001303040111124|08--0018|", ErrBadSquare, 29},
+		{"|0000000000000000000000000|----0018|", ErrBadSquare, 31},
+		{"|0400300002001303040111124|08050008|", ErrOverlap, 33},
+		{"|0400300002001303040111124|08080018|", ErrOverlap, 29},
+		{"|0400300002001303040111124|01050018|", ErrWorkerOnDome, 27},
+		{"|0400300002001303040111124|08050024|", ErrWorkerOnDome, 33},
+		{"|0400300002001303040111124|08050018|w", ErrBadLength, 37},
+		{"|0400300002001303040111124|08050018|x|", ErrSideToMove, 36},
+		{"|0000000000000000000000000|0508----|w|", ErrSideToMove, 36},
+		{"|0400300002001303040111124|08050018|bb", ErrBadSeparator, 37},
+		{"|0400300002001303040111124|08050018|b|12", ErrBadSeparator, 39},
+		{"|0400300002001303040111124|08050018|b|1x|", ErrMoveNumber, 39},
+		{"|0400300002001303040111124|08050018|b|0|", ErrMoveNumber, 38},
+	}
+	for _, tc := range tests {
+		_, err := NewPosition(tc.in)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%q: expected %v, got %v", tc.in, tc.err, err)
+		}
+		var pe *PositionError
+		if !errors.As(err, &pe) || pe.Offset != tc.offset {
+			t.Fatalf("%q: expected a PositionError at offset %v, got %v", tc.in, tc.offset, err)
+		}
+	}
+}
+
+func TestSideToMove(t *testing.T) {
+	tests := []struct {
+		in   string
+		ply  bool
+		move int
+		want string
+	}{
+		// Without a side to move, it is guessed from the parity.
+		{"|0400300002001303040111124|08050018|", false, 0, "|0400300002001303040111124|05080018|w|"},
+		{"|0400300002001303041111124|05080018|", true, 0, "|0400300002001303041111124|05080018|b|"},
+		// An explicit side wins over the guess.
+		{"|0400300002001303040111124|05080018|b|", true, 0, "|0400300002001303040111124|05080018|b|"},
+		{"|0400300002001303041111124|05080018|w|17|", false, 17, "|0400300002001303041111124|05080018|w|17|"},
+	}
+	for _, tc := range tests {
+		position, e := NewPosition(tc.in)
+		if e != nil {
+			t.Fatalf("%q: %v", tc.in, e)
+		}
+		if position.Ply != tc.ply || position.MoveNumber != tc.move {
+			t.Fatalf("%q: expected ply %v and move %v, got %v and %v", tc.in, tc.ply, tc.move, position.Ply, position.MoveNumber)
+		}
+		if got := position.String(); got != tc.want {
+			t.Fatalf("got %q, wanted %q", got, tc.want)
+		}
+		again, e := NewPosition(position.String())
+		if e != nil || again != position {
+			t.Fatalf("%q didn't round trip: %v, %v", tc.in, again, e)
+		}
+	}
+
+	// The move number goes up after Black moves.
+	position, _ := NewPosition("|0400300002001303040111124|05080018|w|3|")
+	position = UpdatePosition(position, LegalBuildMoves(position)[0])
+	if position.MoveNumber != 3 {
+		t.Fatalf("expected move 3 after White moved, got %v", position.MoveNumber)
+	}
+	position = UpdatePosition(position, LegalBuildMoves(position)[0])
+	if position.MoveNumber != 4 {
+		t.Fatalf("expected move 4 after Black moved, got %v", position.MoveNumber)
+	}
+}
+
 func TestLegalMoves(t *testing.T) {
 	position, e := NewPosition("|0400300002001303040111124|08050018|")
 	if e != nil {
@@ -148,18 +280,69 @@ func TestLegalBuildMovesWhite(t *testing.T) {
 		"|0400300002001303040211124|05140018|",
 	}
 
-	moves := legalBuildMoves(position)
+	moves := LegalBuildMoves(position)
 	var got []string
 	for _, mb := range moves {
 		p := UpdatePosition(position, mb)
 		got = append(got, p.String())
 	}
-	if !reflect.DeepEqual(want, got) {
+	if !reflect.DeepEqual(parsedStrings(t, want), got) {
 		t.Fatalf("\nexpected: \n%v, \ngot: \n%v", want, got)
 	}
 
 }
 
+func TestWinningMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		position string
+		move     MoveBuild
+		want     bool
+	}{
+		{"up from 2 to 3", "|2330000000000000000000000|00202224|w|",
+			MoveBuild{occupancy[1], occupancy[2], false, false}, true},
+		{"across from 3 to 3", "|3330000000000000000000000|00202224|w|",
+			MoveBuild{occupancy[1], occupancy[2], false, false}, false},
+		{"up from 1 to 2", "|1200000000000000000000000|00202224|w|",
+			MoveBuild{occupancy[1], occupancy[2], false, false}, false},
+		{"across from 2 to 2", "|2200000000000000000000000|00202224|w|",
+			MoveBuild{occupancy[1], occupancy[2], false, false}, false},
+		{"down from 3 to 2", "|3200000000000000000000000|00202224|w|",
+			MoveBuild{occupancy[1], occupancy[2], false, false}, false},
+		{"second worker up from 2 to 3", "|0000000000000000003200000|00192224|w|",
+			MoveBuild{occupancy[18], occupancy[17], false, true}, true},
+		{"Black up from 2 to 3", "|0000000000000000000002300|00042123|b|",
+			MoveBuild{occupancy[22], occupancy[17], true, false}, true},
+		{"Black across from 3 to 3", "|0000000000000000000003300|00042123|b|",
+			MoveBuild{occupancy[22], occupancy[17], true, false}, false},
+	}
+	for _, tc := range tests {
+		position, e := NewPosition(tc.position)
+		if e != nil {
+			t.Fatalf("%v: %v", tc.name, e)
+		}
+		legal := false
+		for _, mb := range LegalBuildMoves(position) {
+			legal = legal || mb == tc.move
+		}
+		if !legal {
+			t.Fatalf("%v: %+v is not a legal move", tc.name, tc.move)
+		}
+		if got := winningMove(position, tc.move); got != tc.want {
+			t.Fatalf("%v: expected %v, got %v", tc.name, tc.want, got)
+		}
+		// The rest of the rules engine has to agree.
+		outcome := position.Outcome()
+		children := position.Children()
+		if tc.want && (outcome == '?' || children != nil) {
+			t.Fatalf("%v: expected a won position, got outcome %q and %v children", tc.name, outcome, len(children))
+		}
+		if !tc.want && (outcome != '?' || len(children) == 0) {
+			t.Fatalf("%v: expected the game to go on, got outcome %q and %v children", tc.name, outcome, len(children))
+		}
+	}
+}
+
 func TestLegalBuildMovesBlack(t *testing.T) {
 	position, e := NewPosition("|0400300002001303041111124|05080018|")
 	if e != nil {
@@ -203,14 +386,14 @@ func TestLegalBuildMovesBlack(t *testing.T) {
 		"|0400300002001303041111224|05080023|",
 	}
 
-	moves := legalBuildMoves(position)
+	moves := LegalBuildMoves(position)
 	var got []string
 	for _, mb := range moves {
 		p := UpdatePosition(position, mb)
 		got = append(got, p.String())
 	}
 
-	if !reflect.DeepEqual(want, got) {
+	if !reflect.DeepEqual(parsedStrings(t, want), got) {
 		t.Fatalf("\nexpected: \n%v, \ngot: \n%v", want, got)
 	}
 
@@ -225,18 +408,82 @@ func TestLegalBuildMovesBlack(t *testing.T) {
 	}
 }
 
+func TestPlacement(t *testing.T) {
+	position := NewGame()
+	if got, want := position.String(), "|0000000000000000000000000|--------|w|1|"; got != want {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	if parsed, e := NewPosition(position.String()); e != nil || parsed != position {
+		t.Fatalf("empty board didn't round trip: %v, %v", parsed, e)
+	}
+
+	// White places both workers in one move, anywhere on the board.
+	moves := LegalBuildMoves(position)
+	if len(moves) != 300 {
+		t.Fatalf("expected 300 placements for White, got %v", len(moves))
+	}
+	position = UpdatePosition(position, MoveBuild{occupancy[7] | occupancy[12], 0, false, false})
+	if got, want := position.String(), "|0000000000000000000000000|0712----|b|1|"; got != want {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	if !position.Placing || !position.Ply {
+		t.Fatalf("expected Black to place next")
+	}
+	if parsed, e := NewPosition(position.String()); e != nil || parsed != position {
+		t.Fatalf("half placed board didn't round trip: %v, %v", parsed, e)
+	}
+
+	// Black can't use White's squares.
+	moves = LegalBuildMoves(position)
+	if len(moves) != 253 {
+		t.Fatalf("expected 253 placements for Black, got %v", len(moves))
+	}
+	for _, mb := range moves {
+		if mb.Move&(position.A|position.B) != 0 {
+			t.Fatalf("Black may not place on White's workers: %+v", mb)
+		}
+	}
+	position = UpdatePosition(position, MoveBuild{occupancy[17] | occupancy[11], 0, true, false})
+	if got, want := position.String(), "|0000000000000000000000000|07121117|w|2|"; got != want {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	if position.Placing || position.Ply {
+		t.Fatalf("expected White to make the first real move")
+	}
+	if position.Hash != position.ComputeHash() {
+		t.Fatalf("hash wasn't kept up to date while placing")
+	}
+	if len(position.Children()) == 0 {
+		t.Fatalf("expected moves once the workers are down")
+	}
+}
+
 // Mock for game node exploration for a two person game
 // where you can't draw. White moves first, someone wins.
 // Assume we're not sure who wins without more information.
 
-type MockGameNode struct{}
+type MockGameNode struct {
+	Name   string
+	Ply    bool
+	Result rune // 'W' or 'B' for a finished game, otherwise '?'.
+	Kids   []MockGameNode
+}
 
-func (m MockGameNode) Children() {
-	fakeData := [][]MockGameNode{
-		{MockGameNode{}, MockGameNode{}},
+func (m MockGameNode) Children() []GameNode {
+	var ret []GameNode
+	for _, k := range m.Kids {
+		ret = append(ret, k)
 	}
-	fakeData = fakeData
+	return ret
 }
+
+func (m MockGameNode) String() string { return m.Name }
+
+func (m MockGameNode) Outcome() rune { return m.Result }
+
+func (m MockGameNode) WhichPly() bool { return m.Ply }
+
+func (m MockGameNode) Score() (int, int) { return 0, 0 }
 /*
 func TestGameNodeExploration(t *testing.T) {
 	// helper function to sort game results
@@ -0,0 +1,273 @@
+package Santorini
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Scores are from the point of view of the side to move. A won game is worth
+// WinScore less the number of plies it takes to get there, so the search
+// prefers quick wins and slow losses.
+const (
+	WinScore = 1000000
+	infinity = WinScore + 1
+)
+
+// SearchResult is what a search hands back to its caller.
+type SearchResult struct {
+	Move  MoveBuild   // Best move at the root, the zero MoveBuild if there is none.
+	Score int         // Negamax score of the root for the side to move.
+	PV    []MoveBuild // Principal variation, starting with Move.
+	Nodes int         // Positions visited.
+	Depth int         // Plies searched.
+	Stats SearchStats // What the search did to get there.
+}
+
+// Searcher runs depth-limited negamax with alpha-beta pruning over Positions.
+// A Searcher is not safe for concurrent use, but it can run a search on
+// several threads itself; see Threads.
+type Searcher struct {
+	// Table holds results between searches. Callers may replace it to pick
+	// a size or share it, but not between Searchers running at once.
+	Table *TranspositionTable
+	// Evaluator scores the positions at the search horizon. Results in
+	// Table are only good for the Evaluator that produced them.
+	Evaluator Evaluator
+	// Threads is the number of goroutines a search runs on. With one, or
+	// zero, a search gives the same result every time. With more, it is
+	// usually stronger in the same time but no longer repeatable, and
+	// Evaluator has to be safe for concurrent use.
+	Threads int
+	// Tablebases give exact scores for the positions they cover, in place
+	// of searching them. The principal variation stops where they start.
+	Tablebases []*Tablebase
+	// Tracer, if not nil, hears about every node searched, and gets the
+	// stats at the end of each search or iteration. Only the main thread of
+	// a parallel search is traced, though the final stats count the work
+	// of every thread.
+	Tracer Tracer
+
+	nodes   int
+	stats   SearchStats   // Everything but Nodes and Elapsed, for this thread.
+	buffers [][]MoveBuild // Move lists, one per ply so they can be reused.
+
+	// When limited is set, the search gives up as soon as it runs out of
+	// budget and sets aborted. Only IterativeSearch sets it.
+	limited bool
+	aborted bool
+	budget  budget
+	// published is how many of nodes have been added to budget.shared.
+	published int
+}
+
+func NewSearcher() *Searcher {
+	return &Searcher{
+		Table:     NewTranspositionTable(DefaultTableSize),
+		Evaluator: DefaultEvaluator,
+	}
+}
+
+// Search looks depth plies ahead of p with a fresh Searcher.
+func Search(p Position, depth int) SearchResult {
+	return NewSearcher().Search(p, depth)
+}
+
+// Search looks depth plies ahead of p and returns the best move it found,
+// its score and the line the search expects both sides to play.
+func (s *Searcher) Search(p Position, depth int) SearchResult {
+	start := time.Now()
+	if depth < 1 {
+		depth = 1
+	}
+	s.nodes, s.published = 0, 0
+	s.stats = SearchStats{}
+	s.limited, s.aborted = false, false
+	s.budget = budget{}
+	// Positions built by hand have no hash, and the table needs one.
+	p.Hash = p.ComputeHash()
+	helpers := s.startHelpers(context.Background(), p)
+	r := s.searchRoot(p, depth)
+	helped := helpers.stop()
+	r.Nodes += helped.Nodes
+	r.Stats = s.finish(helped, start)
+	return r
+}
+
+// searchRoot runs one depth-limited search from p.
+func (s *Searcher) searchRoot(p Position, depth int) SearchResult {
+	var pv []MoveBuild
+	score := s.negamax(p, depth, 0, -infinity, infinity, &pv)
+
+	r := SearchResult{Score: score, PV: pv, Nodes: s.nodes, Depth: depth}
+	if len(pv) > 0 {
+		r.Move = pv[0]
+	}
+	return r
+}
+
+// negamax scores p for the side to move, searching depth more plies. ply is
+// the distance from the root and pv receives the best line found from p.
+func (s *Searcher) negamax(p Position, depth, ply, alpha, beta int, pv *[]MoveBuild) int {
+	s.nodes++
+	s.stats.reach(ply)
+	if s.Tracer != nil {
+		s.Tracer.Visit(p, ply)
+	}
+	*pv = (*pv)[:0]
+	if s.limited && s.outOfBudget(false) {
+		return 0
+	}
+	// The root has to come back with a move, so it is always searched.
+	if ply > 0 {
+		for _, tb := range s.Tablebases {
+			if r, ok := tb.Probe(p); ok {
+				return r.Score(ply)
+			}
+		}
+	}
+
+	moves := s.movesAt(p, ply)
+	// If I can't move, I lose.
+	if len(moves) == 0 {
+		return -WinScore + ply
+	}
+	// If I can climb, I win and there's nothing left to search.
+	for _, mb := range moves {
+		if winningMove(p, mb) {
+			*pv = append(*pv, mb)
+			return WinScore - ply - 1
+		}
+	}
+	if depth == 0 {
+		return s.Evaluator.Evaluate(p)
+	}
+
+	hashMove := -1
+	if e, ok := s.Table.probe(p.Hash); ok {
+		s.stats.TTHits++
+		hashMove = int(e.move) - 1
+		// Take cutoffs from the table everywhere but the root, which has
+		// to come back with a move.
+		if int(e.depth) >= depth && ply > 0 {
+			score := scoreFromTable(int(e.score), ply)
+			switch {
+			case e.bound == BoundExact:
+				*pv = append(*pv, s.tableLine(p, depth)...)
+				return score
+			case e.bound == BoundLower && score >= beta:
+				return score
+			case e.bound == BoundUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	var line []MoveBuild
+	alphaOrig := alpha
+	best, bestIndex := -infinity, -1
+	s.stats.Expanded++
+	for _, i := range orderMoves(p, moves, hashMove) {
+		s.stats.Children++
+		mb := moves[i]
+		score := -s.negamax(UpdatePosition(p, mb), depth-1, ply+1, -beta, -alpha, &line)
+		if s.aborted {
+			return 0
+		}
+		if score > best {
+			best, bestIndex = score, i
+			*pv = append(append((*pv)[:0], mb), line...)
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			s.stats.Cutoffs++
+			break
+		}
+	}
+
+	bound := BoundExact
+	switch {
+	case best <= alphaOrig:
+		bound = BoundUpper
+	case best >= beta:
+		bound = BoundLower
+	}
+	s.Table.store(p.Hash, depth, bound, scoreToTable(best, ply), bestIndex)
+	return best
+}
+
+// finish fills in the stats for a search that started at start, adding in
+// what its helpers did, and passes them to the tracer.
+func (s *Searcher) finish(helped SearchStats, start time.Time) SearchStats {
+	stats := s.stats
+	stats.Nodes = s.nodes
+	stats.add(helped)
+	stats.Elapsed = time.Since(start)
+	if s.Tracer != nil {
+		s.Tracer.Progress(stats)
+	}
+	return stats
+}
+
+// movesAt generates p's moves into the buffer kept for ply.
+func (s *Searcher) movesAt(p Position, ply int) []MoveBuild {
+	for len(s.buffers) <= ply {
+		s.buffers = append(s.buffers, make([]MoveBuild, 0, 128))
+	}
+	s.buffers[ply] = AppendMoves(s.buffers[ply][:0], p)
+	return s.buffers[ply]
+}
+
+// tableLine follows best moves stored in the table from p, for at most depth
+// plies. It rebuilds the principal variation below a table cutoff.
+func (s *Searcher) tableLine(p Position, depth int) []MoveBuild {
+	var line []MoveBuild
+	for len(line) < depth {
+		e, ok := s.Table.probe(p.Hash)
+		if !ok || e.move == 0 {
+			break
+		}
+		moves := LegalBuildMoves(p)
+		i := int(e.move) - 1
+		if i >= len(moves) {
+			break
+		}
+		line = append(line, moves[i])
+		if winningMove(p, moves[i]) {
+			break
+		}
+		p = UpdatePosition(p, moves[i])
+	}
+	return line
+}
+
+// heightAt is the number of levels built on the square at bit.
+func heightAt(p Position, bit int32) int {
+	h := 0
+	for _, level := range []int32{p.B1, p.B2, p.B3, p.B4} {
+		if level&bit != 0 {
+			h++
+		}
+	}
+	return h
+}
+
+// orderMoves returns the order to search moves in: first, the best move
+// from the table if there is one, then climbing moves, since they are usually
+// the best and so produce the most cutoffs.
+func orderMoves(p Position, moves []MoveBuild, first int) []int {
+	order := make([]int, len(moves))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if (a == first) != (b == first) {
+			return a == first
+		}
+		return heightAt(p, moves[a].Move) > heightAt(p, moves[b].Move)
+	})
+	return order
+}
@@ -0,0 +1,113 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+// minimax is a plain negamax without pruning, to check the search against.
+func minimax(p Position, depth, ply int) int {
+	moves := LegalBuildMoves(p)
+	if len(moves) == 0 {
+		return -WinScore + ply
+	}
+	for _, mb := range moves {
+		if winningMove(p, mb) {
+			return WinScore - ply - 1
+		}
+	}
+	if depth == 0 {
+		return DefaultEvaluator.Evaluate(p)
+	}
+	best := -infinity
+	for _, mb := range moves {
+		if score := -minimax(UpdatePosition(p, mb), depth-1, ply+1); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func TestSearchFindsWin(t *testing.T) {
+	// Black to move, and the piece on 17 can climb onto 12.
+	position, e := NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := Search(position, 3)
+	if got.Move.Move != occupancy[12] {
+		t.Fatalf("expected a move to 12, got %+v", got.Move)
+	}
+	if got.Score != WinScore-1 {
+		t.Fatalf("expected score %v, got %v", WinScore-1, got.Score)
+	}
+	if len(got.PV) != 1 {
+		t.Fatalf("expected a one move principal variation, got %v", got.PV)
+	}
+}
+
+func TestSearchMatchesMinimax(t *testing.T) {
+	positions := []string{
+		"|0400300002001303040111124|05080018|",
+		"|0400300002001303041111124|05080018|",
+		"|0102000100443440032100000|00081922|",
+	}
+	for _, s := range positions {
+		position, e := NewPosition(s)
+		if e != nil {
+			t.Errorf("Error forming position")
+		}
+		for depth := 1; depth <= 2; depth++ {
+			want := minimax(position, depth, 0)
+			got := Search(position, depth)
+			if got.Score != want {
+				t.Fatalf("%v at depth %v: expected score %v, got %v", s, depth, want, got.Score)
+			}
+		}
+	}
+}
+
+func TestSearchPrincipalVariation(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := Search(position, 3)
+	if len(got.PV) == 0 || got.PV[0] != got.Move {
+		t.Fatalf("principal variation %v does not start with the best move %+v", got.PV, got.Move)
+	}
+	// Every move in the line has to be legal where it is played.
+	p := position
+	for i, mb := range got.PV {
+		legal := false
+		for _, m := range LegalBuildMoves(p) {
+			if m == mb {
+				legal = true
+			}
+		}
+		if !legal {
+			t.Fatalf("move %v of the principal variation, %+v, is illegal in %v", i, mb, p)
+		}
+		p = UpdatePosition(p, mb)
+	}
+}
+
+func TestSearchPlacement(t *testing.T) {
+	got := Search(NewGame(), 2)
+	if got.Move.Build != 0 || len(extractSquares(got.Move.Move)) != 2 {
+		t.Fatalf("expected White to place two workers, got %+v", got.Move)
+	}
+	if len(got.PV) != 2 || !got.PV[1].Ply {
+		t.Fatalf("expected Black to place in reply, got %v", got.PV)
+	}
+}
+
+// extractSquares lists the squares set in a bitboard.
+func extractSquares(m int32) []int {
+	var ret []int
+	for sq := 0; sq < 25; sq++ {
+		if m&occupancy[sq] != 0 {
+			ret = append(ret, sq)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,84 @@
+package Santorini
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SearchStats describes the work a search did. Every search hands one back.
+type SearchStats struct {
+	Nodes   int // Nodes visited.
+	Cutoffs int // Times the search stopped looking at a node's moves early.
+	TTHits  int // Positions found in a table of earlier results.
+	Depth   int // Deepest ply reached below the root.
+	// Expanded counts the nodes whose moves were looked at, and Children
+	// the moves looked at from them.
+	Expanded int
+	Children int
+	Elapsed  time.Duration
+}
+
+// BranchingFactor is the average number of moves looked at from each node
+// that was expanded. Cutoffs make it lower than the number of legal moves.
+func (s SearchStats) BranchingFactor() float64 {
+	if s.Expanded == 0 {
+		return 0
+	}
+	return float64(s.Children) / float64(s.Expanded)
+}
+
+// reach notes that the search got ply plies below the root.
+func (s *SearchStats) reach(ply int) {
+	if ply > s.Depth {
+		s.Depth = ply
+	}
+}
+
+// add counts the work in o, done alongside s by another thread, in with
+// s. Elapsed is left alone, since the threads ran at the same time.
+func (s *SearchStats) add(o SearchStats) {
+	s.Nodes += o.Nodes
+	s.Cutoffs += o.Cutoffs
+	s.TTHits += o.TTHits
+	s.Expanded += o.Expanded
+	s.Children += o.Children
+	s.reach(o.Depth)
+}
+
+// Tracer follows a search as it runs. Searches don't trace anything unless
+// a caller gives them a Tracer.
+type Tracer interface {
+	// Visit is called for each node the search enters, ply plies below the
+	// root.
+	Visit(n GameNode, ply int)
+	// Progress is called every so often with the stats so far, and once
+	// more when the search finishes.
+	Progress(stats SearchStats)
+}
+
+// SlogTracer is a Tracer that writes to a slog.Logger: visits at debug
+// level, since there are so many, and progress at info level.
+type SlogTracer struct {
+	Logger *slog.Logger
+}
+
+func (t SlogTracer) Visit(n GameNode, ply int) {
+	if !t.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	t.Logger.LogAttrs(context.Background(), slog.LevelDebug, "visit",
+		slog.Int("ply", ply),
+		slog.String("node", n.String()),
+		slog.String("outcome", string(n.Outcome())))
+}
+
+func (t SlogTracer) Progress(stats SearchStats) {
+	t.Logger.LogAttrs(context.Background(), slog.LevelInfo, "progress",
+		slog.Int("nodes", stats.Nodes),
+		slog.Int("cutoffs", stats.Cutoffs),
+		slog.Int("tt_hits", stats.TTHits),
+		slog.Int("depth", stats.Depth),
+		slog.Float64("branching", stats.BranchingFactor()),
+		slog.Duration("elapsed", stats.Elapsed))
+}
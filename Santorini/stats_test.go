@@ -0,0 +1,134 @@
+package Santorini
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// recordingTracer counts what it hears.
+type recordingTracer struct {
+	visits   int
+	deepest  int
+	progress []SearchStats
+}
+
+func (r *recordingTracer) Visit(n GameNode, ply int) {
+	r.visits++
+	if ply > r.deepest {
+		r.deepest = ply
+	}
+}
+
+func (r *recordingTracer) Progress(stats SearchStats) {
+	r.progress = append(r.progress, stats)
+}
+
+func TestSearchStats(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	tracer := &recordingTracer{}
+	s := NewSearcher()
+	s.Tracer = tracer
+	got := s.Search(position, 3)
+	stats := got.Stats
+	if stats.Nodes != got.Nodes || stats.Nodes != tracer.visits {
+		t.Fatalf("expected %v nodes in the stats and the trace, got %v and %v", got.Nodes, stats.Nodes, tracer.visits)
+	}
+	if stats.Cutoffs == 0 || stats.Depth != tracer.deepest || stats.Depth < 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if b := stats.BranchingFactor(); b <= 0 || b > float64(len(LegalBuildMoves(position))) {
+		t.Fatalf("unlikely branching factor %v", b)
+	}
+	if len(tracer.progress) != 1 || tracer.progress[0] != stats {
+		t.Fatalf("expected the final stats to be traced once, got %v", tracer.progress)
+	}
+
+	// The second search of the same position gets its moves from the table.
+	if got := s.Search(position, 3); got.Stats.TTHits == 0 {
+		t.Fatalf("expected table hits, got %+v", got.Stats)
+	}
+
+	tracer = &recordingTracer{}
+	s.Tracer = tracer
+	got = s.IterativeSearch(context.Background(), position, Limits{Depth: 3}, nil)
+	// One report per iteration, and one at the end.
+	if len(tracer.progress) != 4 || tracer.progress[3] != got.Stats {
+		t.Fatalf("expected four progress reports ending with %+v, got %v", got.Stats, tracer.progress)
+	}
+}
+
+func TestParallelSearchStats(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	tracer := &recordingTracer{}
+	s := NewSearcher()
+	s.Threads = 3
+	s.Tracer = tracer
+	got := s.Search(position, 5)
+	// The tracer only follows the main thread, but the stats count the
+	// helpers too.
+	if got.Stats.Nodes != got.Nodes || got.Stats.Nodes <= tracer.visits || got.Stats.Expanded == 0 {
+		t.Fatalf("expected the helpers' work in %+v, beyond the %v nodes traced", got.Stats, tracer.visits)
+	}
+}
+
+func TestOtherSearchStats(t *testing.T) {
+	tracer := &recordingTracer{}
+	m, stats := ExploreNodeStats(mockTree(), 100, tracer)
+	if m["root"] != 'W' {
+		t.Fatalf("expected White to win the mock tree, got %c", m["root"])
+	}
+	if stats.Nodes == 0 || tracer.visits < stats.Nodes || len(tracer.progress) != 1 {
+		t.Fatalf("unexpected stats %+v and trace %+v", stats, tracer)
+	}
+
+	tracer = &recordingTracer{}
+	m, _, _, stats = ShallowExploreNodeStats(mockTree(), 100, tracer)
+	if len(m) == 0 || stats.Nodes == 0 || tracer.visits != stats.Nodes || stats.Depth != tracer.deepest || len(tracer.progress) != 1 {
+		t.Fatalf("unexpected shallow stats %+v and trace %+v", stats, tracer)
+	}
+
+	mcts := NewMCTS(100, 1)
+	if got := mcts.Search(mockTree()); got.Stats.Nodes == 0 || got.Stats.Depth != 2 {
+		t.Fatalf("unexpected MCTS stats %+v", got.Stats)
+	}
+
+	if got := (ProofSearch{}).Prove(mockTree()); got.Stats.Nodes == 0 || got.Stats.Expanded != got.Expanded {
+		t.Fatalf("unexpected proof search stats %+v", got.Stats)
+	}
+}
+
+func TestSlogTracer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	s := NewSearcher()
+	s.Tracer = SlogTracer{logger}
+	s.Search(position, 1)
+	out := buf.String()
+	if !strings.Contains(out, "msg=visit ply=0 node=|0400300002001303040111124|05080018|") {
+		t.Fatalf("expected the root to be logged, got %v", out)
+	}
+	if !strings.Contains(out, "msg=progress nodes=") {
+		t.Fatalf("expected progress to be logged, got %v", out)
+	}
+
+	// Visits are only logged at debug level.
+	buf.Reset()
+	s.Tracer = SlogTracer{slog.New(slog.NewTextHandler(&buf, nil))}
+	s.Search(position, 1)
+	if out := buf.String(); strings.Contains(out, "msg=visit") || !strings.Contains(out, "msg=progress") {
+		t.Fatalf("expected only progress at info level, got %v", out)
+	}
+}
@@ -0,0 +1,207 @@
+package Santorini
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultSVGSquare is the size of a square, in pixels, when an SVGRenderer
+// doesn't give one.
+const DefaultSVGSquare = 80
+
+// SVGRenderer draws positions and games as SVG images, for pages and bug
+// reports. Buildings are drawn from above as stacked squares, smaller for
+// each level, with a blue dome on top, and workers as discs in their side's
+// color with their letter on. Files and ranks are labelled as in
+// Coordinates, and each square has its number in the corner.
+type SVGRenderer struct {
+	// Square is the size of a square in pixels. Zero means
+	// DefaultSVGSquare.
+	Square int
+}
+
+// Colors of the board.
+const (
+	svgGround     = "#8bc34a"
+	svgLine       = "#5d7f3a"
+	svgLevel      = "#f5f5f0"
+	svgLevelLine  = "#9e9e9e"
+	svgDome       = "#1e5fb4"
+	svgMove       = "#ff6f00"
+	svgBuild      = "#6d4c41"
+	svgLabel      = "#424242"
+	svgWhiteFill  = "#fafafa"
+	svgWhiteInk   = "#212121"
+	svgBlackFill  = "#c62828"
+	svgBlackInk   = "#fafafa"
+	svgWorkerLine = "#212121"
+	svgFontFamily = "sans-serif"
+)
+
+func (r SVGRenderer) size() int {
+	if r.Square <= 0 {
+		return DefaultSVGSquare
+	}
+	return r.Square
+}
+
+// margin is the room around the board for the labels.
+func (r SVGRenderer) margin() int {
+	return r.size() / 3
+}
+
+// center is the middle of square sq.
+func (r SVGRenderer) center(sq int) (int, int) {
+	s := r.size()
+	return r.margin() + sq%5*s + s/2, r.margin() + sq/5*s + s/2
+}
+
+// Position draws p as an SVG document. Unless mb is the zero MoveBuild, it
+// is a move for the side to move in p, and is drawn over the board: an
+// arrow for the worker's move and a dashed one for the build, or rings
+// round the squares of a placement.
+func (r SVGRenderer) Position(p Position, mb MoveBuild) string {
+	var sb strings.Builder
+	r.open(&sb)
+	r.board(&sb, p, mb)
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// Frames draws a game as a series of SVG documents: the position before
+// each move with the move drawn over it, then the position at the end.
+func (r SVGRenderer) Frames(g *Game) []string {
+	var frames []string
+	p := g.Start
+	for _, mb := range g.Moves {
+		frames = append(frames, r.Position(p, mb))
+		p = UpdatePosition(p, mb)
+	}
+	return append(frames, r.Position(p, MoveBuild{}))
+}
+
+// Animate draws a game as one animated SVG document that shows the frames
+// of Frames in turn, each for perFrame, and starts again at the end.
+func (r SVGRenderer) Animate(g *Game, perFrame time.Duration) string {
+	var sb strings.Builder
+	r.open(&sb)
+	n := len(g.Moves) + 1
+	total := perFrame.Seconds() * float64(n)
+	p := g.Start
+	for i := 0; i < n; i++ {
+		var mb MoveBuild
+		if i < len(g.Moves) {
+			mb = g.Moves[i]
+		}
+		// Each frame is shown from i/n of the way through to (i+1)/n.
+		visibility := "hidden"
+		if i == 0 {
+			visibility = "visible"
+		}
+		fmt.Fprintf(&sb, "<g visibility=%q>\n", visibility)
+		if n > 1 {
+			values, times := "hidden;visible;hidden", fmt.Sprintf("0;%.6g;%.6g", float64(i)/float64(n), float64(i+1)/float64(n))
+			switch {
+			case i == 0:
+				values, times = "visible;hidden", fmt.Sprintf("0;%.6g", 1/float64(n))
+			case i == n-1:
+				values, times = "hidden;visible", fmt.Sprintf("0;%.6g", float64(i)/float64(n))
+			}
+			fmt.Fprintf(&sb, "<animate attributeName=\"visibility\" values=%q keyTimes=%q dur=\"%.6gs\" calcMode=\"discrete\" repeatCount=\"indefinite\"/>\n",
+				values, times, total)
+		}
+		r.board(&sb, p, mb)
+		sb.WriteString("</g>\n")
+		if i < len(g.Moves) {
+			p = UpdatePosition(p, mb)
+		}
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// open starts an SVG document, with the arrowheads the moves use.
+func (r SVGRenderer) open(sb *strings.Builder) {
+	width := 5*r.size() + 2*r.margin()
+	fmt.Fprintf(sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=%q>\n",
+		width, width, width, width, svgFontFamily)
+	sb.WriteString("<defs>\n")
+	for _, arrow := range []struct{ id, color string }{{"move", svgMove}, {"build", svgBuild}} {
+		fmt.Fprintf(sb, "<marker id=\"%s-head\" viewBox=\"0 0 10 10\" refX=\"8\" refY=\"5\" markerWidth=\"4\" markerHeight=\"4\" orient=\"auto\">"+
+			"<path d=\"M0,0 L10,5 L0,10 z\" fill=%q/></marker>\n", arrow.id, arrow.color)
+	}
+	sb.WriteString("</defs>\n")
+	fmt.Fprintf(sb, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, width)
+}
+
+// board draws p, and mb over it, unless mb is the zero MoveBuild.
+func (r SVGRenderer) board(sb *strings.Builder, p Position, mb MoveBuild) {
+	s, m := r.size(), r.margin()
+	font := s / 6
+	for i := 0; i < 5; i++ {
+		// Files along the bottom and ranks up the left, as in Coordinates.
+		fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"%d\" text-anchor=\"middle\" fill=%q>%c</text>\n",
+			m+i*s+s/2, m+5*s+m*3/4, font, svgLabel, 'a'+i)
+		fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"%d\" text-anchor=\"middle\" fill=%q>%d</text>\n",
+			m/2, m+i*s+s/2+font/3, font, svgLabel, i+1)
+	}
+	for sq := 0; sq < 25; sq++ {
+		x, y := m+sq%5*s, m+sq/5*s
+		bit := occupancy[sq]
+		fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=%q stroke=%q/>\n", x, y, s, s, svgGround, svgLine)
+		h := heightAt(p, bit)
+		// Each level is a square inside the one below.
+		for level := 1; level <= h && level <= 3; level++ {
+			inset := level * s / 10
+			fmt.Fprintf(sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=%q stroke=%q stroke-width=\"2\"/>\n",
+				x+inset, y+inset, s-2*inset, s-2*inset, svgLevel, svgLevelLine)
+		}
+		cx, cy := x+s/2, y+s/2
+		if h == 4 {
+			fmt.Fprintf(sb, "<circle cx=\"%d\" cy=\"%d\" r=\"%d\" fill=%q/>\n", cx, cy, s/2-3*s/10, svgDome)
+		}
+		fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"%d\" fill=%q>%d</text>\n", x+3, y+font, font*3/4, svgLabel, sq)
+		if worker := workerAt(p, bit); worker != ' ' {
+			fill, ink := svgWhiteFill, svgWhiteInk
+			if worker == 'X' || worker == 'Y' {
+				fill, ink = svgBlackFill, svgBlackInk
+			}
+			fmt.Fprintf(sb, "<circle cx=\"%d\" cy=\"%d\" r=\"%d\" fill=%q stroke=%q stroke-width=\"2\"/>\n", cx, cy, s/5, fill, svgWorkerLine)
+			fmt.Fprintf(sb, "<text x=\"%d\" y=\"%d\" font-size=\"%d\" font-weight=\"bold\" text-anchor=\"middle\" fill=%q>%c</text>\n",
+				cx, cy+font/3, font, ink, worker)
+		}
+	}
+	if mb.Move == 0 {
+		return
+	}
+	if p.Placing {
+		for placed := mb.Move; placed != 0; placed &= placed - 1 {
+			cx, cy := r.center(square(placed & -placed))
+			fmt.Fprintf(sb, "<circle cx=\"%d\" cy=\"%d\" r=\"%d\" fill=\"none\" stroke=%q stroke-width=\"4\" stroke-dasharray=\"6,4\"/>\n",
+				cx, cy, s/4, svgMove)
+		}
+		return
+	}
+	from := square(p.worker(mb.Ply, mb.Piece))
+	r.arrow(sb, from, square(mb.Move), "move", "")
+	if !winningMove(p, mb) {
+		r.arrow(sb, square(mb.Move), square(mb.Build), "build", ` stroke-dasharray="6,4"`)
+	}
+}
+
+// arrow draws an arrow between the centers of two squares, stopping short
+// of the ends so the workers still show.
+func (r SVGRenderer) arrow(sb *strings.Builder, from, to int, kind, extra string) {
+	x1, y1 := r.center(from)
+	x2, y2 := r.center(to)
+	// Squares are next to each other, so trimming a fixed share of the
+	// difference is enough.
+	dx, dy := (x2-x1)/5, (y2-y1)/5
+	color := svgMove
+	if kind == "build" {
+		color = svgBuild
+	}
+	fmt.Fprintf(sb, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=%q stroke-width=\"%d\" stroke-linecap=\"round\" marker-end=\"url(#%s-head)\"%s/>\n",
+		x1+dx, y1+dy, x2-dx, y2-dy, color, r.size()/14, kind, extra)
+}
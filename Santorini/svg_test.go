@@ -0,0 +1,119 @@
+package Santorini
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// svgElements checks doc is well formed XML and counts its elements by name.
+func svgElements(t *testing.T, doc string) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatalf("bad SVG: %v\n%v", err, doc)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestSVGPosition(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	doc := SVGRenderer{}.Position(position, MoveBuild{})
+	counts := svgElements(t, doc)
+	// The background, 25 squares, and a square for each level, with three
+	// under each dome.
+	levels, domes := 0, 0
+	for sq := 0; sq < 25; sq++ {
+		if h := heightAt(position, occupancy[sq]); h < 4 {
+			levels += h
+		} else {
+			levels += 3
+			domes++
+		}
+	}
+	if counts["rect"] != 1+25+levels {
+		t.Fatalf("expected %v rects, got %v", 1+25+levels, counts["rect"])
+	}
+	if got := strings.Count(doc, `fill="`+svgDome+`"`); got != domes || domes == 0 {
+		t.Fatalf("expected %v domes, got %v", domes, got)
+	}
+	if counts["circle"] != domes+4 || counts["line"] != 0 {
+		t.Fatalf("expected domes and workers only, got %v", counts)
+	}
+	if !strings.HasPrefix(doc, `<svg xmlns="http://www.w3.org/2000/svg" width="452" height="452"`) {
+		t.Fatalf("unexpected header %.80q", doc)
+	}
+
+	// A move and a build are two arrows, and a winning climb only one.
+	doc = SVGRenderer{Square: 40}.Position(position, LegalBuildMoves(position)[0])
+	if counts := svgElements(t, doc); counts["line"] != 2 || !strings.Contains(doc, `width="226"`) {
+		t.Fatalf("expected two arrows on a smaller board, got %v", counts)
+	}
+	position, e = NewPosition("|1002000100443440022100001|01081723|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	if counts := svgElements(t, SVGRenderer{}.Position(position, position.Result().Move)); counts["line"] != 1 {
+		t.Fatalf("expected one arrow for the climb, got %v", counts)
+	}
+
+	// A placement rings both squares.
+	p := NewGame()
+	doc = SVGRenderer{}.Position(p, LegalBuildMoves(p)[0])
+	if counts := svgElements(t, doc); counts["circle"] != 2 || counts["line"] != 0 {
+		t.Fatalf("expected two rings, got %v", counts)
+	}
+}
+
+func TestSVGGame(t *testing.T) {
+	g := NewGameRecord()
+	playOut(t, g, 6)
+	frames := SVGRenderer{}.Frames(g)
+	if len(frames) != 7 {
+		t.Fatalf("expected a frame per move and one for the end, got %v", len(frames))
+	}
+	for i, frame := range frames {
+		counts := svgElements(t, frame)
+		if counts["svg"] != 1 || i < 6 && counts["line"]+counts["circle"] < 2 {
+			t.Fatalf("frame %v: unexpected elements %v", i, counts)
+		}
+	}
+	if frames[6] != (SVGRenderer{}).Position(g.Position(), MoveBuild{}) {
+		t.Fatalf("expected the last frame to be the final position")
+	}
+
+	doc := SVGRenderer{}.Animate(g, 500*time.Millisecond)
+	counts := svgElements(t, doc)
+	if counts["svg"] != 1 || counts["g"] != 7 || counts["animate"] != 7 || counts["marker"] != 2 {
+		t.Fatalf("expected one document with seven animated frames, got %v", counts)
+	}
+	for _, want := range []string{
+		`<g visibility="visible">`,
+		`values="visible;hidden" keyTimes="0;0.142857" dur="3.5s"`,
+		`values="hidden;visible;hidden" keyTimes="0;0.142857;0.285714"`,
+		`values="hidden;visible" keyTimes="0;0.857143"`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("expected %q in the animation", want)
+		}
+	}
+
+	// A game with no moves is a still.
+	if doc := (SVGRenderer{}).Animate(NewGameRecord(), time.Second); strings.Contains(doc, "<animate") {
+		t.Fatalf("expected no animation for one frame")
+	}
+}
@@ -0,0 +1,144 @@
+package Santorini
+
+// Symmetry is one of the 8 rotations and reflections of the 5x5 board.
+type Symmetry uint8
+
+const (
+	Identity       Symmetry = iota
+	Rotate90                // Quarter turn clockwise.
+	Rotate180               // Half turn.
+	Rotate270               // Quarter turn anticlockwise.
+	FlipHorizontal          // Mirror left to right.
+	FlipVertical            // Mirror top to bottom.
+	Transpose               // Mirror in the diagonal through squares 0 and 24.
+	AntiTranspose           // Mirror in the diagonal through squares 4 and 20.
+)
+
+// Symmetries lists every transform of the board, starting with Identity.
+var Symmetries = []Symmetry{
+	Identity, Rotate90, Rotate180, Rotate270,
+	FlipHorizontal, FlipVertical, Transpose, AntiTranspose,
+}
+
+// symmetrySquares[s][sq] is where square sq ends up under s.
+var symmetrySquares [8][25]int
+
+func init() {
+	for _, s := range Symmetries {
+		for sq := 0; sq < 25; sq++ {
+			r, c := sq/5, sq%5
+			switch s {
+			case Identity:
+			case Rotate90:
+				r, c = c, 4-r
+			case Rotate180:
+				r, c = 4-r, 4-c
+			case Rotate270:
+				r, c = 4-c, r
+			case FlipHorizontal:
+				c = 4 - c
+			case FlipVertical:
+				r = 4 - r
+			case Transpose:
+				r, c = c, r
+			case AntiTranspose:
+				r, c = 4-c, 4-r
+			}
+			symmetrySquares[s][sq] = r*5 + c
+		}
+	}
+}
+
+// Inverse is the transform that undoes s.
+func (s Symmetry) Inverse() Symmetry {
+	switch s {
+	case Rotate90:
+		return Rotate270
+	case Rotate270:
+		return Rotate90
+	}
+	return s
+}
+
+// Square maps a square number through s.
+func (s Symmetry) Square(sq int) int {
+	return symmetrySquares[s][sq]
+}
+
+// Mask maps every square set in a bitboard through s.
+func (s Symmetry) Mask(m int32) int32 {
+	var out int32
+	for m != 0 {
+		sq := square(m)
+		out |= occupancy[s.Square(sq)]
+		m &= m - 1
+	}
+	return out
+}
+
+// Apply returns p transformed by s, with each side's workers put back in
+// order and the hash recomputed.
+func (s Symmetry) Apply(p Position) Position {
+	p.B1 = s.Mask(p.B1)
+	p.B2 = s.Mask(p.B2)
+	p.B3 = s.Mask(p.B3)
+	p.B4 = s.Mask(p.B4)
+	p.A = s.Mask(p.A)
+	p.B = s.Mask(p.B)
+	p.X = s.Mask(p.X)
+	p.Y = s.Mask(p.Y)
+	if p.A > p.B {
+		p.A, p.B = p.B, p.A
+	}
+	if p.X > p.Y {
+		p.X, p.Y = p.Y, p.X
+	}
+	p.Hash = p.ComputeHash()
+	return p
+}
+
+// ApplyMove maps mb, a move played from p, onto the board s.Apply(p). The
+// worker that moves may change from first to second, since workers are kept
+// in square order.
+func (s Symmetry) ApplyMove(p Position, mb MoveBuild) MoveBuild {
+	worker := p.worker(mb.Ply, mb.Piece)
+	q := s.Apply(p)
+	second := q.B
+	if mb.Ply {
+		second = q.Y
+	}
+	return MoveBuild{
+		Move:  s.Mask(mb.Move),
+		Build: s.Mask(mb.Build),
+		Ply:   mb.Ply,
+		Piece: !p.Placing && s.Mask(worker) == second,
+	}
+}
+
+// lessBoard orders positions by their bitboards, for picking a canonical one.
+func lessBoard(p, q Position) bool {
+	a := [8]int32{p.B1, p.B2, p.B3, p.B4, p.A, p.B, p.X, p.Y}
+	b := [8]int32{q.B1, q.B2, q.B3, q.B4, q.A, q.B, q.X, q.Y}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// Canonical returns the smallest of the 8 symmetric versions of p, and the
+// transform that produces it from p. Symmetric positions share a canonical
+// form, so tables keyed by it need about an eighth of the entries. A move
+// found for the canonical position maps back with
+//
+//	sym.Inverse().ApplyMove(canonical, move)
+func (p Position) Canonical() (Position, Symmetry) {
+	best, bestSym := Identity.Apply(p), Identity
+	for _, s := range Symmetries[1:] {
+		if q := s.Apply(p); lessBoard(q, best) {
+			best, bestSym = q, s
+		}
+	}
+	return best, bestSym
+}
@@ -0,0 +1,85 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+func TestSymmetrySquares(t *testing.T) {
+	tests := []struct {
+		s    Symmetry
+		want [4]int // Where the corners 0, 4, 20 and 24 go.
+	}{
+		{Identity, [4]int{0, 4, 20, 24}},
+		{Rotate90, [4]int{4, 24, 0, 20}},
+		{Rotate180, [4]int{24, 20, 4, 0}},
+		{Rotate270, [4]int{20, 0, 24, 4}},
+		{FlipHorizontal, [4]int{4, 0, 24, 20}},
+		{FlipVertical, [4]int{20, 24, 0, 4}},
+		{Transpose, [4]int{0, 20, 4, 24}},
+		{AntiTranspose, [4]int{24, 4, 20, 0}},
+	}
+	for _, tc := range tests {
+		for i, sq := range []int{0, 4, 20, 24} {
+			if got := tc.s.Square(sq); got != tc.want[i] {
+				t.Fatalf("symmetry %v sends %v to %v, expected %v", tc.s, sq, got, tc.want[i])
+			}
+		}
+		for sq := 0; sq < 25; sq++ {
+			if got := tc.s.Inverse().Square(tc.s.Square(sq)); got != sq {
+				t.Fatalf("symmetry %v and its inverse send %v to %v", tc.s, sq, got)
+			}
+		}
+		if tc.s.Square(12) != 12 {
+			t.Fatalf("symmetry %v moves the center", tc.s)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	want, sym := position.Canonical()
+	if sym.Apply(position) != want {
+		t.Fatalf("symmetry %v does not produce the canonical position", sym)
+	}
+	for _, s := range Symmetries {
+		got, _ := s.Apply(position).Canonical()
+		if got != want {
+			t.Fatalf("under symmetry %v got canonical\n%v, expected\n%v", s, got, want)
+		}
+	}
+}
+
+func TestSymmetryApplyMove(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	for _, s := range Symmetries {
+		q := s.Apply(position)
+		legal := make(map[MoveBuild]bool)
+		for _, mb := range LegalBuildMoves(q) {
+			legal[mb] = true
+		}
+		moves := LegalBuildMoves(position)
+		if len(moves) != len(legal) {
+			t.Fatalf("symmetry %v: %v moves, but %v after the transform", s, len(moves), len(legal))
+		}
+		for _, mb := range moves {
+			mapped := s.ApplyMove(position, mb)
+			if !legal[mapped] {
+				t.Fatalf("symmetry %v maps %+v to illegal move %+v", s, mb, mapped)
+			}
+			// Playing the move and then transforming has to agree with
+			// transforming and then playing the mapped move.
+			if got, want := UpdatePosition(q, mapped), s.Apply(UpdatePosition(position, mb)); got != want {
+				t.Fatalf("symmetry %v, move %+v: got\n%v, expected\n%v", s, mb, got, want)
+			}
+			if back := s.Inverse().ApplyMove(q, mapped); back != mb {
+				t.Fatalf("symmetry %v: %+v came back as %+v", s, mb, back)
+			}
+		}
+	}
+}
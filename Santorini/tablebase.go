@@ -0,0 +1,295 @@
+package Santorini
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// MaxTablebaseSquares is the most free squares a tablebase can cover. Each
+// extra square multiplies the size by about 10, and 7 already takes 33MB.
+const MaxTablebaseSquares = 7
+
+// tablebaseMagic starts every tablebase file.
+var tablebaseMagic = [4]byte{'S', 'T', 'B', '1'}
+
+var (
+	ErrTablebaseSquares = errors.New("tablebase needs from 4 to 7 free squares")
+	ErrTablebaseFormat  = errors.New("not a tablebase file")
+)
+
+// TablebaseResult is the exact value of a position from a tablebase.
+type TablebaseResult struct {
+	Win      bool // Whether the side to move wins.
+	Distance int  // Plies to the end of the game with best play from both sides.
+}
+
+// Score is the search score of the result for a position ply plies from the
+// root, the same score a search would give it if it saw to the end.
+func (r TablebaseResult) Score(ply int) int {
+	if r.Win {
+		return WinScore - ply - r.Distance
+	}
+	return -WinScore + ply + r.Distance
+}
+
+// Tablebase holds the solution of every position where play is confined to
+// a few squares: those in Pattern can have any height, and all the rest are
+// domed. Each position with all four workers placed is solved by retrograde
+// analysis, working back from the positions with the most building.
+//
+// Nothing can be built outside the pattern, so it also covers positions
+// whose free squares are a subset of it.
+type Tablebase struct {
+	Pattern uint32
+
+	squares []int   // The squares in Pattern, in order.
+	slot    [25]int // slot[sq] is sq's index in squares, or -1.
+	// values has a byte per position: 0 if no game can reach it, otherwise
+	// the distance plus one, with the top bit set for a win.
+	values []byte
+}
+
+const tablebaseWin = 0x80
+
+func newTablebase(pattern uint32) (*Tablebase, error) {
+	pattern &= boardMask
+	n := bits.OnesCount32(pattern)
+	if n < 4 || n > MaxTablebaseSquares {
+		return nil, ErrTablebaseSquares
+	}
+	t := &Tablebase{Pattern: pattern}
+	for sq := range t.slot {
+		t.slot[sq] = -1
+		if pattern&(1<<uint(sq)) != 0 {
+			t.slot[sq] = len(t.squares)
+			t.squares = append(t.squares, sq)
+		}
+	}
+	t.values = make([]byte, t.size())
+	return t, nil
+}
+
+// pairs is the number of ways to put two workers on m squares.
+func pairs(m int) int {
+	return m * (m - 1) / 2
+}
+
+// pairRank numbers the pair of squares i < j from 0 to pairs(m)-1.
+func pairRank(i, j int) int {
+	return j*(j-1)/2 + i
+}
+
+func pow5(n int) int {
+	r := 1
+	for ; n > 0; n-- {
+		r *= 5
+	}
+	return r
+}
+
+// size is the number of positions in the table. Heights come first, five
+// to a square, then White's pair of squares, then Black's pair out of the
+// squares White left, then the side to move.
+func (t *Tablebase) size() int {
+	n := len(t.squares)
+	return pow5(n) * pairs(n) * pairs(n-2) * 2
+}
+
+// index finds p in the table. It reports false when p isn't covered.
+func (t *Tablebase) index(p Position) (int, bool) {
+	outside := boardMask &^ t.Pattern
+	if p.Placing || uint32(p.B4)&outside != outside || p.A == 0 || p.X == 0 {
+		return 0, false
+	}
+	heights := 0
+	for i := len(t.squares) - 1; i >= 0; i-- {
+		heights = heights*5 + heightAt(p, occupancy[t.squares[i]])
+	}
+	a, b := t.slot[square(p.A)], t.slot[square(p.B)]
+	x, y := t.slot[square(p.X)], t.slot[square(p.Y)]
+	if a < 0 || b < 0 || x < 0 || y < 0 {
+		return 0, false
+	}
+	// Black's squares are numbered as though White's weren't there.
+	skip := func(k int) int {
+		r := k
+		if k > a {
+			r--
+		}
+		if k > b {
+			r--
+		}
+		return r
+	}
+	n := len(t.squares)
+	i := heights
+	i = i*pairs(n) + pairRank(a, b)
+	i = i*pairs(n-2) + pairRank(skip(x), skip(y))
+	i = i * 2
+	if p.Ply {
+		i++
+	}
+	return i, true
+}
+
+// Probe looks p up, and reports false if the table doesn't cover it.
+func (t *Tablebase) Probe(p Position) (TablebaseResult, bool) {
+	i, ok := t.index(p)
+	if !ok || t.values[i] == 0 {
+		return TablebaseResult{}, false
+	}
+	v := t.values[i]
+	return TablebaseResult{Win: v&tablebaseWin != 0, Distance: int(v&^tablebaseWin) - 1}, true
+}
+
+// BestMove returns a move that keeps to the value Probe gives p: the
+// quickest win, or the slowest loss.
+func (t *Tablebase) BestMove(p Position) (MoveBuild, bool) {
+	want, ok := t.Probe(p)
+	if !ok {
+		return MoveBuild{}, false
+	}
+	for _, mb := range LegalBuildMoves(p) {
+		if winningMove(p, mb) {
+			return mb, true
+		}
+		r, ok := t.Probe(UpdatePosition(p, mb))
+		if ok && r.Win != want.Win && r.Distance+1 == want.Distance {
+			return mb, true
+		}
+	}
+	return MoveBuild{}, false
+}
+
+// GenerateTablebase solves every position on pattern, the set of squares
+// that aren't domed.
+func GenerateTablebase(pattern uint32) (*Tablebase, error) {
+	t, err := newTablebase(pattern)
+	if err != nil {
+		return nil, err
+	}
+	n := len(t.squares)
+	// Every move builds a level, so a position's children all have one more
+	// level in total than it does. Solving the height settings with the most
+	// levels first means the children are always solved already.
+	byLevels := make([][]int, 4*n+1)
+	for h := 0; h < pow5(n); h++ {
+		levels := 0
+		for r := h; r > 0; r /= 5 {
+			levels += r % 5
+		}
+		byLevels[levels] = append(byLevels[levels], h)
+	}
+	buf := make([]MoveBuild, 0, 128)
+	for levels := 4 * n; levels >= 0; levels-- {
+		for _, h := range byLevels[levels] {
+			p := t.board(h)
+			for a := 0; a < n; a++ {
+				for b := a + 1; b < n; b++ {
+					for x := 0; x < n; x++ {
+						for y := x + 1; y < n; y++ {
+							if x == a || x == b || y == a || y == b {
+								continue
+							}
+							p.A, p.B = occupancy[t.squares[a]], occupancy[t.squares[b]]
+							p.X, p.Y = occupancy[t.squares[x]], occupancy[t.squares[y]]
+							for _, ply := range []bool{false, true} {
+								p.Ply = ply
+								buf = t.solve(p, buf)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// board builds the position with the heights numbered h, and no workers.
+func (t *Tablebase) board(h int) Position {
+	domed := int32(boardMask &^ t.Pattern)
+	p := Position{B1: domed, B2: domed, B3: domed, B4: domed}
+	for _, sq := range t.squares {
+		level := h % 5
+		h /= 5
+		for i, mask := range []*int32{&p.B1, &p.B2, &p.B3, &p.B4} {
+			if i < level {
+				*mask |= occupancy[sq]
+			}
+		}
+	}
+	return p
+}
+
+// solve works out the value of p from its children's, and stores it.
+func (t *Tablebase) solve(p Position, buf []MoveBuild) []MoveBuild {
+	i, _ := t.index(p)
+	// Workers can't stand on domes, and only get to level 3 by winning.
+	if uint32(p.A|p.B|p.X|p.Y)&uint32(p.B3) != 0 {
+		return buf
+	}
+	buf = AppendMoves(buf[:0], p)
+	win, distance := false, 0
+	for _, mb := range buf {
+		if winningMove(p, mb) {
+			win, distance = true, 1
+			break
+		}
+		r, ok := t.Probe(UpdatePosition(p, mb))
+		if !ok {
+			continue
+		}
+		switch {
+		case !r.Win && (!win || r.Distance+1 < distance):
+			// The quickest way to beat the opponent.
+			win, distance = true, r.Distance+1
+		case r.Win && !win && r.Distance+1 > distance:
+			// The slowest way to lose, unless there's a win.
+			distance = r.Distance + 1
+		}
+	}
+	v := byte(distance + 1)
+	if win {
+		v |= tablebaseWin
+	}
+	t.values[i] = v
+	return buf
+}
+
+// WriteTo writes the table to w: the magic bytes "STB1", the pattern as a
+// little endian uint32, then a byte for each position.
+func (t *Tablebase) WriteTo(w io.Writer) (int64, error) {
+	var header [8]byte
+	copy(header[:], tablebaseMagic[:])
+	binary.LittleEndian.PutUint32(header[4:], t.Pattern)
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(t.values)
+	return int64(n + m), err
+}
+
+// ReadTablebase reads a table written by WriteTo.
+func ReadTablebase(r io.Reader) (*Tablebase, error) {
+	br := bufio.NewReader(r)
+	var header [8]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, ErrTablebaseFormat
+	}
+	if [4]byte{header[0], header[1], header[2], header[3]} != tablebaseMagic {
+		return nil, ErrTablebaseFormat
+	}
+	t, err := newTablebase(binary.LittleEndian.Uint32(header[4:]))
+	if err != nil {
+		return nil, ErrTablebaseFormat
+	}
+	if _, err := io.ReadFull(br, t.values); err != nil {
+		return nil, ErrTablebaseFormat
+	}
+	return t, nil
+}
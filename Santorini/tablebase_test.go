@@ -0,0 +1,135 @@
+package Santorini
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// The top left 2x3 corner of the board.
+const cornerPattern = 1<<0 | 1<<1 | 1<<2 | 1<<5 | 1<<6 | 1<<7
+
+// randomTablebasePosition puts random heights on the pattern's squares and
+// the workers on four of them.
+func randomTablebasePosition(t *Tablebase, r *rand.Rand) Position {
+	p := t.board(r.Intn(pow5(len(t.squares))))
+	order := r.Perm(len(t.squares))
+	workers := []*int32{&p.A, &p.B, &p.X, &p.Y}
+	for i, w := range workers {
+		*w = occupancy[t.squares[order[i]]]
+	}
+	if p.A > p.B {
+		p.A, p.B = p.B, p.A
+	}
+	if p.X > p.Y {
+		p.X, p.Y = p.Y, p.X
+	}
+	p.Ply = r.Intn(2) == 1
+	p.Hash = p.ComputeHash()
+	return p
+}
+
+func TestTablebaseMatchesSearch(t *testing.T) {
+	tb, err := GenerateTablebase(cornerPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is left to build after 24 plies, so a search that deep sees
+	// to the end of every game.
+	s := NewSearcher()
+	r := rand.New(rand.NewSource(1))
+	checked := 0
+	for checked < 100 {
+		p := randomTablebasePosition(tb, r)
+		want, ok := tb.Probe(p)
+		if !ok {
+			continue
+		}
+		checked++
+		if got := s.Search(p, 25); got.Score != want.Score(0) {
+			t.Fatalf("%v: table says %+v, score %v, search says %v", p, want, want.Score(0), got.Score)
+		}
+		if mb, ok := tb.BestMove(p); ok {
+			if got, _ := tb.Probe(UpdatePosition(p, mb)); !winningMove(p, mb) && got.Win == want.Win {
+				t.Fatalf("%v: best move %+v doesn't keep to %+v", p, mb, want)
+			}
+		} else if want.Distance > 0 {
+			t.Fatalf("%v: no best move for %+v", p, want)
+		}
+	}
+}
+
+func TestTablebaseCoverage(t *testing.T) {
+	tb, err := GenerateTablebase(cornerPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, e := NewPosition("|0004400044444444444444444|00010506|w|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	if _, ok := tb.Probe(p); !ok {
+		t.Fatalf("expected %v to be covered", p)
+	}
+	// A free square outside the pattern takes the position out of the table.
+	p, e = NewPosition("|0004400044444444444444440|00010506|w|")
+	if e != nil {
+		t.Fatalf("Error forming position: %v", e)
+	}
+	if _, ok := tb.Probe(p); ok {
+		t.Fatalf("expected %v not to be covered", p)
+	}
+	if _, err := GenerateTablebase(1<<0 | 1<<1 | 1<<2); err != ErrTablebaseSquares {
+		t.Fatalf("expected ErrTablebaseSquares for three squares, got %v", err)
+	}
+}
+
+func TestTablebaseReadWrite(t *testing.T) {
+	tb, err := GenerateTablebase(1<<0 | 1<<1 | 1<<5 | 1<<6 | 1<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := tb.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := 8 + tb.size(); buf.Len() != want {
+		t.Fatalf("expected %v bytes, got %v", want, buf.Len())
+	}
+	read, err := ReadTablebase(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Pattern != tb.Pattern || !bytes.Equal(read.values, tb.values) {
+		t.Fatalf("table changed in a round trip")
+	}
+
+	if _, err := ReadTablebase(bytes.NewReader([]byte("STB2\x00\x00\x00\x00"))); err != ErrTablebaseFormat {
+		t.Fatalf("expected ErrTablebaseFormat for a bad magic number, got %v", err)
+	}
+	if _, err := ReadTablebase(bytes.NewReader(buf.Bytes()[:100])); err != ErrTablebaseFormat {
+		t.Fatalf("expected ErrTablebaseFormat for a short file, got %v", err)
+	}
+}
+
+func TestSearchProbesTablebase(t *testing.T) {
+	tb, err := GenerateTablebase(cornerPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSearcher()
+	s.Tablebases = []*Tablebase{tb}
+	r := rand.New(rand.NewSource(2))
+	for checked := 0; checked < 20; {
+		p := randomTablebasePosition(tb, r)
+		want, ok := tb.Probe(p)
+		if !ok || want.Distance == 0 {
+			continue
+		}
+		checked++
+		// One ply is enough when every child is in the table.
+		if got := s.Search(p, 1); got.Score != want.Score(0) {
+			t.Fatalf("%v: table says %v, search says %v", p, want.Score(0), got.Score)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package Santorini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Renderer draws boards for a terminal, more compactly than DrawBoard: a
+// line for each row, with files a to e across the top and ranks 1 to 5 down
+// the side, as in Coordinates.
+//
+// Each square shows its height, 0 to 3, or ## for a dome, and the letter of
+// any worker on it. The last move's destination and build are bracketed,
+// as in [2A], and highlighted squares, such as where a worker can go, are
+// marked as in <1 >. With Color set, heights, sides and marks are shown in
+// ANSI colors too. Without it the board is plain ASCII, for output that
+// isn't a terminal.
+type Renderer struct {
+	Color bool
+}
+
+// ANSI 256 color codes.
+var (
+	// heightColors are the backgrounds of levels 0 to 3, getting paler as
+	// they go up, and of domes.
+	heightColors = [5]int{151, 187, 223, 230, 25}
+	// sideColors are the colors of White's and Black's workers.
+	sideColors  = [2]int{21, 160}
+	digitColor  = 238
+	domeColor   = 231
+	lastColor   = 130
+	targetColor = 28
+)
+
+// Render draws p. last, unless it is the zero MoveBuild, is the move that
+// led to p, and highlight holds the squares to mark, one bit per square as
+// in Position.
+func (r Renderer) Render(p Position, last MoveBuild, highlight int32) string {
+	var sb strings.Builder
+	sb.WriteString("    a    b    c    d    e\n")
+	for row := 0; row < 5; row++ {
+		fmt.Fprintf(&sb, "%d ", row+1)
+		for col := 0; col < 5; col++ {
+			bit := occupancy[row*5+col]
+			open, close := " ", " "
+			markColor := 0
+			switch {
+			case last.Move&bit != 0 || !p.Placing && last.Build&bit != 0:
+				open, close, markColor = "[", "]", lastColor
+			case highlight&bit != 0:
+				open, close, markColor = "<", ">", targetColor
+			}
+			sb.WriteString(" ")
+			r.cell(&sb, p, bit, open, close, markColor)
+		}
+		sb.WriteString("\n")
+	}
+	side, letters := sideName(p.Ply), "A B"
+	if p.Ply {
+		letters = "X Y"
+	}
+	verb := "move"
+	if p.Placing {
+		verb = "place"
+	}
+	fmt.Fprintf(&sb, "%v (%v) to %v\n", side, r.paint(letters, sideColors[boolIndex(p.Ply)], true), verb)
+	return sb.String()
+}
+
+// cell draws the square on bit, between open and close.
+func (r Renderer) cell(sb *strings.Builder, p Position, bit int32, open, close string, markColor int) {
+	h := heightAt(p, bit)
+	if r.Color {
+		fmt.Fprintf(sb, "\x1b[48;5;%dm", heightColors[h])
+	}
+	sb.WriteString(r.paint(open, markColor, true))
+	switch worker := workerAt(p, bit); {
+	case h == 4:
+		sb.WriteString(r.paint("##", domeColor, false))
+	case worker != ' ':
+		ply := worker == 'X' || worker == 'Y'
+		sb.WriteString(r.paint(string(rune('0'+h)), digitColor, false))
+		sb.WriteString(r.paint(string(worker), sideColors[boolIndex(ply)], true))
+	default:
+		sb.WriteString(r.paint(string(rune('0'+h))+" ", digitColor, false))
+	}
+	sb.WriteString(r.paint(close, markColor, true))
+	if r.Color {
+		sb.WriteString("\x1b[0m")
+	}
+}
+
+// paint colors s, in bold if asked, when r.Color is set. Color 0 leaves s
+// as it is. The background is left alone.
+func (r Renderer) paint(s string, color int, bold bool) string {
+	if !r.Color || color == 0 || strings.TrimSpace(s) == "" {
+		return s
+	}
+	if bold {
+		return fmt.Sprintf("\x1b[1;38;5;%dm%s\x1b[22;39m", color, s)
+	}
+	return fmt.Sprintf("\x1b[38;5;%dm%s\x1b[39m", color, s)
+}
@@ -0,0 +1,75 @@
+package Santorini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPlain(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	// White's worker moved to 5 and built on 10, and can go to 6 and 11.
+	last := MoveBuild{Move: occupancy[5], Build: occupancy[10]}
+	got := Renderer{}.Render(position, last, occupancy[6]|occupancy[11])
+	want := "" +
+		"    a    b    c    d    e\n" +
+		"1   0X   ##   0    0    3  \n" +
+		"2  [0A] <0 >  0    0B   2  \n" +
+		"3  [0 ] <0 >  1    3    0  \n" +
+		"4   3    0    ##   0Y   1  \n" +
+		"5   1    1    1    2    ## \n" +
+		"White (A B) to move\n"
+	if got != want {
+		t.Fatalf("expected\n%v\ngot\n%v", want, got)
+	}
+	for _, c := range got {
+		if c > 0x7E && c != '\n' {
+			t.Fatalf("expected plain ASCII, got %q", c)
+		}
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	p := NewGame()
+	if got := (Renderer{Color: true}).Render(p, MoveBuild{}, 0); !strings.Contains(got, "\x1b[48;5;151m") || !strings.Contains(got, "to place") {
+		t.Fatalf("expected colored level 0 squares, got %q", got)
+	}
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	got := Renderer{Color: true}.Render(position, MoveBuild{Move: occupancy[5], Build: occupancy[10]}, occupancy[6])
+	for _, want := range []string{
+		"\x1b[1;38;5;21mA\x1b[22;39m",  // White's worker.
+		"\x1b[1;38;5;160mY\x1b[22;39m", // Black's worker.
+		"\x1b[48;5;25m",                // A dome.
+		"\x1b[1;38;5;130m[",            // The last move.
+		"\x1b[1;38;5;28m<",             // A highlight.
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in %q", want, got)
+		}
+	}
+	// Without the escapes, it is the plain board.
+	plain := Renderer{}.Render(position, MoveBuild{Move: occupancy[5], Build: occupancy[10]}, occupancy[6])
+	if stripped := stripANSI(got); stripped != plain {
+		t.Fatalf("expected the colors to be all that differs, got\n%v", stripped)
+	}
+}
+
+// stripANSI removes color escapes from s.
+func stripANSI(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b {
+			for s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,125 @@
+package Santorini
+
+import (
+	"sync/atomic"
+)
+
+// Bound says how a stored score relates to the true value of a position.
+type Bound uint8
+
+const (
+	BoundExact Bound = iota + 1 // The score is exact.
+	BoundLower                  // The search failed high, the value is at least the score.
+	BoundUpper                  // The search failed low, the value is at most the score.
+)
+
+// DefaultTableSize is the number of entries in a Searcher's table unless the
+// caller supplies their own.
+const DefaultTableSize = 1 << 20
+
+type ttEntry struct {
+	key   uint64
+	score int32
+	move  uint16 // Index of the best move in LegalBuildMoves order, plus one.
+	depth int8
+	bound Bound
+}
+
+// pack squeezes everything but the key into one word.
+func (e ttEntry) pack() uint64 {
+	return uint64(uint32(e.score)) | uint64(e.move)<<32 | uint64(uint8(e.depth))<<48 | uint64(e.bound)<<56
+}
+
+func unpack(key, data uint64) ttEntry {
+	return ttEntry{
+		key:   key,
+		score: int32(uint32(data)),
+		move:  uint16(data >> 32),
+		depth: int8(data >> 48),
+		bound: Bound(data >> 56),
+	}
+}
+
+// ttSlot holds one entry in two words that are read and written atomically.
+// The key is stored XORed with the data, so a slot half overwritten by
+// another goroutine doesn't match either key and reads as a miss.
+type ttSlot struct {
+	check uint64 // key ^ data
+	data  uint64
+}
+
+// TranspositionTable is a fixed-size hash table of search results keyed by
+// Zobrist hash. When two positions land on the same slot the newer one wins,
+// unless it is the same position searched less deeply.
+//
+// A table is safe for concurrent use, so the threads of a parallel search
+// can share one.
+type TranspositionTable struct {
+	entries []ttSlot
+	mask    uint64
+}
+
+// NewTranspositionTable makes a table with room for size entries, rounded
+// down to a power of two.
+func NewTranspositionTable(size int) *TranspositionTable {
+	n := 1
+	for n*2 <= size {
+		n *= 2
+	}
+	return &TranspositionTable{
+		entries: make([]ttSlot, n),
+		mask:    uint64(n - 1),
+	}
+}
+
+// Clear forgets everything in the table. It must not run alongside a search
+// using the table.
+func (t *TranspositionTable) Clear() {
+	for i := range t.entries {
+		t.entries[i] = ttSlot{}
+	}
+}
+
+func (t *TranspositionTable) probe(key uint64) (ttEntry, bool) {
+	slot := &t.entries[key&t.mask]
+	data := atomic.LoadUint64(&slot.data)
+	e := unpack(atomic.LoadUint64(&slot.check)^data, data)
+	return e, e.bound != 0 && e.key == key
+}
+
+func (t *TranspositionTable) store(key uint64, depth int, bound Bound, score int, move int) {
+	if e, ok := t.probe(key); ok && int(e.depth) > depth {
+		return
+	}
+	data := ttEntry{
+		score: int32(score),
+		move:  uint16(move + 1),
+		depth: int8(depth),
+		bound: bound,
+	}.pack()
+	slot := &t.entries[key&t.mask]
+	atomic.StoreUint64(&slot.data, data)
+	atomic.StoreUint64(&slot.check, key^data)
+}
+
+// Win scores depend on the distance from the root, so they are stored as
+// distance from the position itself and converted back when probed.
+func scoreToTable(score, ply int) int {
+	switch {
+	case score > WinScore-1000:
+		return score + ply
+	case score < -WinScore+1000:
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTable(score, ply int) int {
+	switch {
+	case score > WinScore-1000:
+		return score - ply
+	case score < -WinScore+1000:
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,55 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	tt := NewTranspositionTable(1000)
+	if len(tt.entries) != 512 {
+		t.Fatalf("expected 512 entries, got %v", len(tt.entries))
+	}
+	if _, ok := tt.probe(42); ok {
+		t.Fatalf("empty table reported a hit")
+	}
+
+	tt.store(42, 3, BoundLower, 17, 5)
+	e, ok := tt.probe(42)
+	if !ok || e.depth != 3 || e.bound != BoundLower || e.score != 17 || e.move != 6 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	// A shallower result for the same position doesn't replace a deeper one.
+	tt.store(42, 1, BoundExact, 0, 0)
+	if e, _ := tt.probe(42); e.depth != 3 {
+		t.Fatalf("shallow store replaced a deeper entry: %+v", e)
+	}
+	// Another position in the same slot does.
+	tt.store(42+512, 1, BoundExact, 0, 0)
+	if _, ok := tt.probe(42); ok {
+		t.Fatalf("entry was not replaced")
+	}
+
+	tt.Clear()
+	if _, ok := tt.probe(42 + 512); ok {
+		t.Fatalf("Clear left an entry behind")
+	}
+}
+
+func TestTableWinScores(t *testing.T) {
+	// A win three plies from here, seen from five plies into the search.
+	score := WinScore - 8
+	stored := scoreToTable(score, 5)
+	if stored != WinScore-3 {
+		t.Fatalf("expected %v stored, got %v", WinScore-3, stored)
+	}
+	// Reached again two plies into the search, it is a win in five.
+	if got := scoreFromTable(stored, 2); got != WinScore-5 {
+		t.Fatalf("expected %v, got %v", WinScore-5, got)
+	}
+	if got := scoreFromTable(scoreToTable(-WinScore+8, 5), 2); got != -WinScore+5 {
+		t.Fatalf("expected %v, got %v", -WinScore+5, got)
+	}
+	if got := scoreToTable(300, 5); got != 300 {
+		t.Fatalf("ordinary scores should be stored as they are, got %v", got)
+	}
+}
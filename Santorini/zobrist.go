@@ -0,0 +1,79 @@
+package Santorini
+
+import (
+	"hash/fnv"
+	"math/bits"
+)
+
+// Zobrist keys. A position's hash is the XOR of one key for every level bit
+// set in B1..B4, one key for every worker (by side, since A and B are
+// interchangeable), and zobristBlack when it is Black's turn.
+var (
+	zobristHeight [4][25]uint64
+	zobristWorker [2][25]uint64
+	zobristBlack  uint64
+)
+
+func init() {
+	// splitmix64 from a fixed seed, so hashes are the same from run to run.
+	seed := uint64(0x5a4e746f72696e69)
+	next := func() uint64 {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for level := range zobristHeight {
+		for sq := range zobristHeight[level] {
+			zobristHeight[level][sq] = next()
+		}
+	}
+	for side := range zobristWorker {
+		for sq := range zobristWorker[side] {
+			zobristWorker[side][sq] = next()
+		}
+	}
+	zobristBlack = next()
+}
+
+// square turns a single bit occupancy mask into its square number.
+func square(bit int32) int {
+	return bits.TrailingZeros32(uint32(bit))
+}
+
+// hashBits XORs together keys[sq] for every square set in mask.
+func hashBits(keys *[25]uint64, mask int32) uint64 {
+	var h uint64
+	for m := uint32(mask); m != 0; m &= m - 1 {
+		h ^= keys[bits.TrailingZeros32(m)]
+	}
+	return h
+}
+
+// ComputeHash works out the Zobrist hash of p from scratch. NewPosition and
+// UpdatePosition keep the Hash field up to date, so this is only needed for
+// positions built by hand.
+func (p Position) ComputeHash() uint64 {
+	var h uint64
+	for level, mask := range []int32{p.B1, p.B2, p.B3, p.B4} {
+		h ^= hashBits(&zobristHeight[level], mask)
+	}
+	h ^= hashBits(&zobristWorker[0], p.A|p.B)
+	h ^= hashBits(&zobristWorker[1], p.X|p.Y)
+	if p.Ply {
+		h ^= zobristBlack
+	}
+	return h
+}
+
+// nodeKey is the memo key for a game node. Positions use their Zobrist hash,
+// anything else falls back to hashing its string.
+func nodeKey(n GameNode) uint64 {
+	if p, ok := n.(Position); ok {
+		return p.Hash
+	}
+	h := fnv.New64a()
+	h.Write([]byte(n.String()))
+	return h.Sum64()
+}
@@ -0,0 +1,50 @@
+package Santorini
+
+import (
+	"testing"
+)
+
+func TestHashFollowsUpdatePosition(t *testing.T) {
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	// Walk the first few plies of the tree and make sure the incremental hash
+	// always agrees with one worked out from scratch.
+	var walk func(p Position, depth int)
+	walk = func(p Position, depth int) {
+		if p.Hash != p.ComputeHash() {
+			t.Fatalf("incremental hash %x of %v does not match %x", p.Hash, p, p.ComputeHash())
+		}
+		if depth == 0 {
+			return
+		}
+		for _, mb := range LegalBuildMoves(p) {
+			walk(UpdatePosition(p, mb), depth-1)
+		}
+	}
+	walk(position, 2)
+}
+
+func TestHashDistinguishesPositions(t *testing.T) {
+	seen := make(map[uint64]string)
+	position, e := NewPosition("|0400300002001303040111124|05080018|")
+	if e != nil {
+		t.Errorf("Error forming position")
+	}
+	for _, c := range position.Children() {
+		for _, gc := range c.Children() {
+			p := gc.(Position)
+			if s, ok := seen[p.Hash]; ok && s != p.String() {
+				t.Fatalf("%v and %v share hash %x", s, p, p.Hash)
+			}
+			seen[p.Hash] = p.String()
+		}
+	}
+	// Same board, different side to move.
+	black := position
+	black.Ply = !black.Ply
+	if black.ComputeHash() == position.Hash {
+		t.Fatalf("side to move does not change the hash")
+	}
+}
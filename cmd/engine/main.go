@@ -0,0 +1,31 @@
+// Command engine runs the Santorini engine on standard input and output,
+// speaking the text protocol described in package engine, for GUIs and
+// tournament scripts to drive as a subprocess.
+//
+//	engine -threads 4
+package main
+
+import (
+	"flag"
+	"fmt"
+	"main/Santorini"
+	"main/engine"
+	"os"
+	"runtime"
+)
+
+func main() {
+	threads := flag.Int("threads", runtime.NumCPU(), "threads each search runs on")
+	coordinates := flag.Bool("coordinates", false, "write moves with squares as coordinates, such as c3, rather than numbers")
+	flag.Parse()
+
+	e := engine.New(os.Stdout)
+	e.Threads = *threads
+	if *coordinates {
+		e.Notation = Santorini.Coordinates
+	}
+	if err := e.Run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,36 @@
+// Command gameserver hosts live games over WebSockets, as described in
+// package gameserver.
+//
+//	gameserver -addr :8081 -engine-time 2s -engines 4 -max-rooms 500
+package main
+
+import (
+	"flag"
+	"log"
+	"main/gameserver"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "address to listen on")
+	engineTime := flag.Duration("engine-time", time.Second, "longest the engine thinks about each move")
+	engines := flag.Int("engines", 2, "most engine searches run at once, across all rooms")
+	maxRooms := flag.Int("max-rooms", gameserver.DefaultMaxRooms, "most rooms open at once")
+	idle := flag.Duration("idle-timeout", gameserver.DefaultIdleTimeout, "how long a room nobody is connected to is kept")
+	flag.Parse()
+
+	s := gameserver.New(*engines)
+	s.EngineLimits.MoveTime = *engineTime
+	s.MaxRooms = *maxRooms
+	s.IdleTimeout = *idle
+	mux := http.NewServeMux()
+	mux.Handle("/rooms/", s)
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Printf("listening on %v", *addr)
+	log.Fatal(hs.ListenAndServe())
+}
@@ -0,0 +1,289 @@
+// Command santorini plays a game of Santorini at the terminal between two
+// players, with hints from the engine for whoever asks.
+//
+// Moves can be typed as their number on the menu printed each turn, or in
+// move notation, such as A c3-d3^e3 or A 12-13^14. Workers are placed by
+// typing their two square numbers, such as 7 17, or as A b2 B c4. The board
+// is drawn in color on a terminal, and in plain ASCII otherwise. The game
+// record is printed on the way out.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"main/Santorini"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The commands the REPL takes besides moves.
+const commands = "hint, undo, redo, history, quit"
+
+// notation is how moves are shown, to match the board's labels. Moves can
+// be typed in either notation.
+const notation = Santorini.Coordinates
+
+// repl is a game being played at the terminal.
+type repl struct {
+	game *Santorini.Game
+	// undone are the moves taken back that can be played again, most
+	// recently undone last.
+	undone []Santorini.MoveBuild
+	// first and second are the menu of moves for each worker of the side
+	// to move, numbered from 100 and 200.
+	first, second []Santorini.MoveBuild
+	// searcher answers hints. It is made for the first one and kept, so
+	// later hints start from what earlier searches learned.
+	searcher *Santorini.Searcher
+}
+
+// printMenu prints the moves open to the side to move, numbered from 100
+// for the first worker and 200 for the second, and keeps them for pick.
+func (r *repl) printMenu(p Santorini.Position) {
+	r.first, r.second = nil, nil
+	for _, mb := range Santorini.LegalBuildMoves(p) {
+		if mb.Piece {
+			r.second = append(r.second, mb)
+		} else {
+			r.first = append(r.first, mb)
+		}
+	}
+	for i, moves := range [][]Santorini.MoveBuild{r.first, r.second} {
+		fmt.Printf("\n")
+		for j, mb := range moves {
+			fmt.Printf("%v: %-13v", (i+1)*100+j, notation.Format(p, mb))
+			if j%5 == 4 {
+				fmt.Printf("\n")
+			}
+		}
+		fmt.Printf("\n")
+	}
+}
+
+// pick reads input as a number on the menu, or as a move in notation.
+func (r *repl) pick(p Santorini.Position, input string) (Santorini.MoveBuild, error) {
+	i, err := strconv.Atoi(input)
+	if err != nil {
+		return Santorini.ParseMove(p, input)
+	}
+	moves := r.first
+	if i >= 200 {
+		i -= 200
+		moves = r.second
+	} else {
+		i -= 100
+	}
+	if i < 0 || i >= len(moves) {
+		return Santorini.MoveBuild{}, fmt.Errorf("there's no move %v", input)
+	}
+	return moves[i], nil
+}
+
+// place reads input as the two squares to put the side to move's workers
+// on, or as a placement in notation.
+func place(p Santorini.Position, input string) (Santorini.MoveBuild, error) {
+	var first, second int
+	if _, err := fmt.Sscan(input, &first, &second); err != nil {
+		return Santorini.ParseMove(p, input)
+	}
+	if first < 0 || first > 24 || second < 0 || second > 24 || first == second {
+		return Santorini.MoveBuild{}, fmt.Errorf("pick two different squares from 0 to 24")
+	}
+	return Santorini.MoveBuild{Move: 1<<first | 1<<second, Ply: p.Ply}, nil
+}
+
+// play plays mb, which can't be redone after anything but a redo.
+func (r *repl) play(mb Santorini.MoveBuild) error {
+	if err := r.game.Play(mb); err != nil {
+		if errors.Is(err, Santorini.ErrIllegalMove) {
+			return fmt.Errorf("that isn't legal here")
+		}
+		return err
+	}
+	r.undone = nil
+	return nil
+}
+
+// undo takes back the last move.
+func (r *repl) undo() {
+	n := len(r.game.Moves)
+	if n == 0 {
+		fmt.Printf("Nothing to undo.\n")
+		return
+	}
+	r.undone = append(r.undone, r.game.Moves[n-1])
+	r.game.Moves = r.game.Moves[:n-1]
+}
+
+// redo plays the last move undone again.
+func (r *repl) redo() {
+	n := len(r.undone)
+	if n == 0 {
+		fmt.Printf("Nothing to redo.\n")
+		return
+	}
+	r.game.Moves = append(r.game.Moves, r.undone[n-1])
+	r.undone = r.undone[:n-1]
+}
+
+// printHistory lists the moves played so far, one to a line.
+func (r *repl) printHistory() {
+	if len(r.game.Moves) == 0 {
+		fmt.Printf("No moves yet.\n")
+		return
+	}
+	// Moves are numbered on from the start position, as in the record.
+	p := r.game.Start
+	number := p.MoveNumber
+	if number == 0 {
+		number = 1
+	}
+	for _, mb := range r.game.Moves {
+		text := notation.Format(p, mb)
+		if mb.Ply {
+			fmt.Printf("%v... %v\n", number, text)
+			number++
+		} else {
+			fmt.Printf("%v. %v\n", number, text)
+		}
+		p = Santorini.UpdatePosition(p, mb)
+	}
+}
+
+// printRecord prints the game so far in the package's game record format.
+func (r *repl) printRecord() {
+	r.game.Tags["Date"] = time.Now().Format("2006.01.02")
+	fmt.Printf("\n")
+	if err := Santorini.NewGameWriter(os.Stdout).Write(r.game); err != nil {
+		fmt.Printf("Couldn't print the game record: %v\n", err)
+	}
+}
+
+// hint asks the engine for a move and prints it with its menu number. The
+// engine gets a couple of seconds, so the player isn't kept waiting.
+func (r *repl) hint(p Santorini.Position) {
+	if r.searcher == nil {
+		r.searcher = Santorini.NewSearcher()
+		r.searcher.Threads = runtime.NumCPU()
+	}
+	result := r.searcher.IterativeSearch(context.Background(), p,
+		Santorini.Limits{MoveTime: 2 * time.Second}, func(info Santorini.Info) {
+			fmt.Printf("depth %v score %v nodes %v nps %v\n", info.Depth, info.Score, info.Nodes, info.NPS)
+		})
+	if result.Move.Move == 0 {
+		fmt.Printf("No moves to suggest.\n")
+		return
+	}
+	for i, moves := range [][]Santorini.MoveBuild{r.first, r.second} {
+		for j, mb := range moves {
+			if mb == result.Move {
+				fmt.Printf("Hint: %v (%v)\n", (i+1)*100+j, notation.Format(p, mb))
+				return
+			}
+		}
+	}
+}
+
+// isTerminal reports whether f is a terminal, rather than a file or a
+// pipe that colors would only clutter.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printBoard draws p with the last move marked, and where the side to move
+// can go highlighted.
+func (r *repl) printBoard(p Santorini.Position, renderer Santorini.Renderer) {
+	var last Santorini.MoveBuild
+	if n := len(r.game.Moves); n > 0 {
+		last = r.game.Moves[n-1]
+	}
+	var targets int32
+	if !p.Placing && !r.game.Ended().Over() {
+		for _, mb := range Santorini.LegalBuildMoves(p) {
+			targets |= mb.Move
+		}
+	}
+	fmt.Print(renderer.Render(p, last, targets))
+}
+
+func main() {
+	// Games start from an empty board, and each side places its workers.
+	r := &repl{game: Santorini.NewGameRecord()}
+	renderer := Santorini.Renderer{
+		Color: isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+	}
+	input := bufio.NewScanner(os.Stdin)
+	for {
+		p := r.game.Position()
+		r.printBoard(p, renderer)
+		// Say why the game ended, once it has.
+		ended := r.game.Ended()
+		switch {
+		case ended.Over():
+			fmt.Printf("\n%v\nType undo to take a move back, or quit.\n", ended)
+		case p.Placing:
+			letters := "A and B"
+			if p.Ply {
+				letters = "X and Y"
+			}
+			fmt.Printf("\nPlace %v (two squares, e.g. 7 17):\n", letters)
+		default:
+			r.printMenu(p)
+		}
+
+		if !input.Scan() {
+			break
+		}
+		line := strings.TrimSpace(input.Text())
+		switch line {
+		case "":
+			continue
+		case "quit", "exit":
+			r.printRecord()
+			return
+		case "undo":
+			r.undo()
+			continue
+		case "redo":
+			r.redo()
+			continue
+		case "history":
+			r.printHistory()
+			continue
+		case "hint":
+			switch {
+			case ended.Over():
+				fmt.Printf("No hints, the game is over.\n")
+			case p.Placing:
+				fmt.Printf("No hints until the workers are on the board.\n")
+			default:
+				r.hint(p)
+			}
+			continue
+		}
+
+		var mb Santorini.MoveBuild
+		var err error
+		switch {
+		case ended.Over():
+			err = fmt.Errorf("the game is over")
+		case p.Placing:
+			mb, err = place(p, line)
+		default:
+			mb, err = r.pick(p, line)
+		}
+		if err == nil {
+			err = r.play(mb)
+		}
+		if err != nil {
+			fmt.Printf("Can't play that: %v. Commands are %v.\n", err, commands)
+		}
+	}
+	r.printRecord()
+}
@@ -0,0 +1,35 @@
+// Command server answers questions about Santorini positions over HTTP, as
+// described in package server.
+//
+//	server -addr :8080 -searches 4
+package main
+
+import (
+	"flag"
+	"log"
+	"main/server"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	searches := flag.Int("searches", 2, "most evaluations to run at once")
+	threads := flag.Int("threads", 1, "threads each evaluation runs on")
+	maxTime := flag.Duration("max-time", server.DefaultMaxMoveTime, "longest an evaluation may search for")
+	flag.Parse()
+
+	s := server.New(*searches, *threads)
+	s.MaxMoveTime = *maxTime
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           s,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		// Long enough to wait for a searcher and then search.
+		WriteTimeout: s.QueueTimeout + s.MaxMoveTime + 5*time.Second,
+		IdleTimeout:  time.Minute,
+	}
+	log.Printf("listening on %v", *addr)
+	log.Fatal(hs.ListenAndServe())
+}
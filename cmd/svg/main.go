@@ -0,0 +1,82 @@
+// Command svg draws a Santorini position, or a game from a record, as SVG.
+//
+//	svg -position '|0000000000000000000000000|06120816|w|2|' -move 'A b2-c2^c1' -o board.svg
+//	svg -record games.txt -game 2 -o game.svg
+//	svg -record games.txt -frames frames/
+//
+// A game is drawn as one animated image, or with -frames as an image for
+// each move in the directory given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"main/Santorini"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	position := flag.String("position", "", "position to draw, in the form Position.String gives; empty for the start")
+	move := flag.String("move", "", "move to draw over the position, in either notation")
+	record := flag.String("record", "", "file of game records to draw a game from, instead of a position")
+	game := flag.Int("game", 1, "which game in the record to draw, counting from 1")
+	frames := flag.String("frames", "", "directory to write a frame for each move of the game to, instead of animating it")
+	frameTime := flag.Duration("frame-time", time.Second, "how long each move is shown for in an animation")
+	square := flag.Int("square", Santorini.DefaultSVGSquare, "size of a square in pixels")
+	out := flag.String("o", "", "file to write the image to; empty for standard output")
+	flag.Parse()
+
+	r := Santorini.SVGRenderer{Square: *square}
+	if *record == "" {
+		p := Santorini.NewGame()
+		var err error
+		if *position != "" {
+			p, err = Santorini.NewPosition(*position)
+			check(err)
+		}
+		var mb Santorini.MoveBuild
+		if *move != "" {
+			mb, err = Santorini.ParseMove(p, *move)
+			check(err)
+		}
+		write(*out, r.Position(p, mb))
+		return
+	}
+
+	f, err := os.Open(*record)
+	check(err)
+	games, err := Santorini.ReadGames(f)
+	f.Close()
+	check(err)
+	if *game < 1 || *game > len(games) {
+		check(fmt.Errorf("%v has %v games, not a game %v", *record, len(games), *game))
+	}
+	g := games[*game-1]
+	if *frames == "" {
+		write(*out, r.Animate(g, *frameTime))
+		return
+	}
+	check(os.MkdirAll(*frames, 0o755))
+	for i, frame := range r.Frames(g) {
+		write(filepath.Join(*frames, fmt.Sprintf("frame%03d.svg", i)), frame)
+	}
+}
+
+// write writes doc to the file called name, or to standard output.
+func write(name, doc string) {
+	if name == "" {
+		_, err := os.Stdout.WriteString(doc)
+		check(err)
+		return
+	}
+	check(os.WriteFile(name, []byte(doc), 0o644))
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+// Command tablebase solves every Santorini position where play is confined
+// to a few squares, and writes the results out as a tablebase file for the
+// search to probe.
+//
+//	tablebase -squares 0,1,2,5,6,7 -o corner.stb
+package main
+
+import (
+	"flag"
+	"fmt"
+	"main/Santorini"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	squares := flag.String("squares", "", "comma separated squares, 0 to 24, that aren't domed")
+	out := flag.String("o", "tablebase.stb", "file to write the tablebase to")
+	flag.Parse()
+
+	var pattern uint32
+	for _, field := range strings.Split(*squares, ",") {
+		sq, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || sq < 0 || sq > 24 {
+			fmt.Fprintf(os.Stderr, "bad square %q\n", field)
+			os.Exit(2)
+		}
+		pattern |= 1 << uint(sq)
+	}
+
+	start := time.Now()
+	tb, err := Santorini.GenerateTablebase(pattern)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	n, err := tb.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %v bytes to %v in %v\n", n, *out, time.Since(start).Round(time.Millisecond))
+}
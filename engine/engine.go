@@ -0,0 +1,286 @@
+// Package engine runs the Santorini search behind a line based text
+// protocol, in the style of UCI, so that other programs can drive it as a
+// subprocess: one command to a line on the way in, one reply to a line on
+// the way out.
+//
+// The commands are:
+//
+//	isready                      reply readyok, even while searching
+//	newgame                      forget earlier searches and start again
+//	position startpos [moves M...]
+//	position P [moves M...]      set up P, in the form NewPosition reads,
+//	                             and play the moves from it
+//	go [depth N] [movetime MS] [nodes N] [infinite]
+//	stop                         end the search and give its best move
+//	quit
+//
+// Moves are written as Santorini.Numeric writes them, such as A 6-7^2 or,
+// while placing workers, A 6 B 12. A search started by go reports each
+// iteration as it finishes, and ends with its best move, or none if the
+// side to move has no move:
+//
+//	info depth 4 score cp 35 nodes 5120 nps 512000 time 10 pv A 6-7^2 X 8-9^3 ...
+//	bestmove A 6-7^2
+//
+// Scores are from the side to move's point of view: cp and a score from
+// the Evaluator, or win or loss and the number of plies to the end of the
+// game. A command that can't be carried out gets a line starting with
+// error. While a search runs, only isready, stop and quit are accepted, so
+// clients should wait for bestmove before sending anything else.
+package engine
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"main/Santorini"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrBadCommand     = errors.New("malformed command")
+	ErrBusy           = errors.New("a search is running")
+)
+
+// Engine reads commands and writes replies for one client.
+type Engine struct {
+	// Threads is the number of threads each search runs on.
+	Threads int
+	// Notation is how moves are written in replies. Moves are read in
+	// either notation.
+	Notation Santorini.Notation
+
+	searcher *Santorini.Searcher
+	position Santorini.Position
+
+	mu  sync.Mutex // Guards out.
+	out io.Writer
+
+	// cancel stops the running search, and done is closed when it has
+	// finished. Both are nil when there is no search.
+	cancel   context.CancelFunc
+	done     chan struct{}
+	infinite bool // Set when the running search has no limits.
+}
+
+// New makes an Engine that writes its replies to out, set up at the start
+// of a game.
+func New(out io.Writer) *Engine {
+	return &Engine{
+		Threads:  1,
+		searcher: Santorini.NewSearcher(),
+		position: Santorini.NewGame(),
+		out:      out,
+	}
+}
+
+// Run carries out commands from in until quit or the end of the input.
+// At the end of the input, a search with limits is left to finish and a
+// search without them is stopped.
+func (e *Engine) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if e.Execute(scanner.Text()) {
+			return nil
+		}
+	}
+	if e.infinite {
+		e.stop()
+	}
+	e.wait()
+	return scanner.Err()
+}
+
+// Execute carries out one command, and reports whether it was quit.
+func (e *Engine) Execute(line string) (quit bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	var err error
+	switch fields[0] {
+	case "isready":
+		e.printf("readyok")
+	case "newgame":
+		err = e.newGame()
+	case "position":
+		err = e.setPosition(fields[1:])
+	case "go":
+		err = e.goSearch(fields[1:])
+	case "stop":
+		e.stop()
+	case "quit":
+		e.stop()
+		return true
+	default:
+		err = fmt.Errorf("%w %q", ErrUnknownCommand, fields[0])
+	}
+	if err != nil {
+		e.printf("error %v", err)
+	}
+	return false
+}
+
+// printf writes one line of reply.
+func (e *Engine) printf(format string, args ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// searching reports whether a search is still running.
+func (e *Engine) searching() bool {
+	if e.done == nil {
+		return false
+	}
+	select {
+	case <-e.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (e *Engine) newGame() error {
+	if e.searching() {
+		return ErrBusy
+	}
+	e.searcher.Table.Clear()
+	e.position = Santorini.NewGame()
+	return nil
+}
+
+// setPosition reads the arguments of position: startpos or a position,
+// then optionally moves and the moves to play from it.
+func (e *Engine) setPosition(args []string) error {
+	if e.searching() {
+		return ErrBusy
+	}
+	if len(args) == 0 || len(args) > 1 && args[1] != "moves" {
+		return ErrBadCommand
+	}
+	p := Santorini.NewGame()
+	if args[0] != "startpos" {
+		var err error
+		if p, err = Santorini.NewPosition(args[0]); err != nil {
+			return err
+		}
+	}
+	if len(args) > 2 {
+		moves, err := Santorini.ParseMoves(p, strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		for _, mb := range moves {
+			p = Santorini.UpdatePosition(p, mb)
+		}
+	}
+	e.position = p
+	return nil
+}
+
+// parseLimits reads the arguments of go.
+func parseLimits(args []string) (limits Santorini.Limits, infinite bool, err error) {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			infinite = true
+			continue
+		}
+		if i+1 == len(args) {
+			return limits, false, ErrBadCommand
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n <= 0 {
+			return limits, false, ErrBadCommand
+		}
+		switch args[i] {
+		case "depth":
+			limits.Depth = n
+		case "movetime":
+			limits.MoveTime = time.Duration(n) * time.Millisecond
+		case "nodes":
+			limits.Nodes = n
+		default:
+			return limits, false, ErrBadCommand
+		}
+		i++
+	}
+	// A search with no limits at all only ends when it is stopped.
+	infinite = infinite || limits == Santorini.Limits{}
+	return limits, infinite, nil
+}
+
+// goSearch starts a search of the current position in the background.
+func (e *Engine) goSearch(args []string) error {
+	if e.searching() {
+		return ErrBusy
+	}
+	limits, infinite, err := parseLimits(args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	e.cancel, e.done, e.infinite = cancel, done, infinite
+	e.searcher.Threads = e.Threads
+	p := e.position
+	go func() {
+		defer close(done)
+		r := e.searcher.IterativeSearch(ctx, p, limits, func(info Santorini.Info) {
+			e.printf("info depth %v score %v nodes %v nps %v time %v pv %v",
+				info.Depth, formatScore(info.Score), info.Nodes, info.NPS,
+				info.Elapsed.Milliseconds(), e.formatLine(p, info.PV))
+		})
+		if r.Move.Move == 0 {
+			e.printf("bestmove none")
+			return
+		}
+		e.printf("bestmove %v", e.Notation.Format(p, r.Move))
+	}()
+	return nil
+}
+
+// stop ends the running search, if there is one, and waits for it to give
+// its best move.
+func (e *Engine) stop() {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wait()
+}
+
+// wait waits for the running search, if there is one, to finish.
+func (e *Engine) wait() {
+	if e.done != nil {
+		<-e.done
+	}
+	e.cancel, e.done, e.infinite = nil, nil, false
+}
+
+// formatScore writes a search score as cp and the score, or win or loss
+// and the plies until the game ends.
+func formatScore(score int) string {
+	switch {
+	case score > Santorini.WinScore/2:
+		return "win " + strconv.Itoa(Santorini.WinScore-score)
+	case score < -Santorini.WinScore/2:
+		return "loss " + strconv.Itoa(Santorini.WinScore+score)
+	}
+	return "cp " + strconv.Itoa(score)
+}
+
+// formatLine writes moves played one after another from p.
+func (e *Engine) formatLine(p Santorini.Position, moves []Santorini.MoveBuild) string {
+	text := make([]string, len(moves))
+	for i, mb := range moves {
+		text[i] = e.Notation.Format(p, mb)
+		p = Santorini.UpdatePosition(p, mb)
+	}
+	return strings.Join(text, " ")
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer that a search can write to while a test
+// reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// run feeds commands to a new Engine and returns its replies, one to a line.
+func run(t *testing.T, commands ...string) []string {
+	t.Helper()
+	var out syncBuffer
+	if err := New(&out).Run(strings.NewReader(strings.Join(commands, "\n"))); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func TestSearch(t *testing.T) {
+	lines := run(t, "isready", "position startpos moves A 6 B 12 X 8 Y 16", "go depth 3")
+	if len(lines) != 5 || lines[0] != "readyok" {
+		t.Fatalf("expected readyok, three iterations and a move, got %q", lines)
+	}
+	for i, line := range lines[1:4] {
+		if !strings.HasPrefix(line, "info depth "+string(rune('1'+i))+" score cp ") || !strings.Contains(line, " pv A ") {
+			t.Fatalf("unexpected info line %q", line)
+		}
+	}
+	if !strings.HasPrefix(lines[4], "bestmove ") || !strings.Contains(lines[3], " pv "+strings.TrimPrefix(lines[4], "bestmove ")) {
+		t.Fatalf("expected the best move to start the last pv, got %q", lines)
+	}
+}
+
+func TestWinningPosition(t *testing.T) {
+	// Black, to move, can climb from 17 onto 12.
+	lines := run(t, "position |1002000100443440022100001|01081723| moves", "go depth 5")
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "bestmove X 17-12") {
+		t.Fatalf("expected the climb, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "score win 1 ") {
+		t.Fatalf("expected a win in one, got %q", lines[0])
+	}
+}
+
+func TestStop(t *testing.T) {
+	var out syncBuffer
+	e := New(&out)
+	e.Execute("position startpos moves A 6 B 12 X 8 Y 16")
+	e.Execute("go infinite")
+	e.Execute("isready")
+	e.Execute("go depth 1")
+	if quit := e.Execute("stop"); quit {
+		t.Fatalf("stop shouldn't quit")
+	}
+	got := out.String()
+	if !strings.Contains(got, "readyok\n") || !strings.Contains(got, "error a search is running\n") {
+		t.Fatalf("expected readyok and a refusal while searching, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\n") || strings.Count(got, "bestmove ") != 1 {
+		t.Fatalf("expected one best move once stopped, got %q", got)
+	}
+	if !e.Execute("quit") {
+		t.Fatalf("expected quit to quit")
+	}
+}
+
+func TestBadCommands(t *testing.T) {
+	for command, want := range map[string]string{
+		"bogus":                           `error unknown command "bogus"`,
+		"position":                        "error malformed command",
+		"position startpos A 6 B 12":      "error malformed command",
+		"position startpos moves A 6 B 6": `error santorini: bad move "A 6 B 6": move isn't legal in this position`,
+		"position |00|":                   "error ",
+		"go depth":                        "error malformed command",
+		"go depth -1":                     "error malformed command",
+		"go speed 3":                      "error malformed command",
+	} {
+		lines := run(t, command)
+		if len(lines) != 1 || !strings.HasPrefix(lines[0], want) {
+			t.Fatalf("%q: expected %q, got %q", command, want, lines)
+		}
+	}
+}
+
+func TestNoMoves(t *testing.T) {
+	// White's workers on 0 and 1 are walled in by domes.
+	lines := run(t, "position |0040044400000000000000000|00012324|w|", "go depth 2")
+	if lines[len(lines)-1] != "bestmove none" {
+		t.Fatalf("expected no move, got %q", lines)
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	for score, want := range map[int]string{
+		0:       "cp 0",
+		-250:    "cp -250",
+		999997:  "win 3",
+		-999996: "loss 4",
+	} {
+		if got := formatScore(score); got != want {
+			t.Fatalf("%v: expected %q, got %q", score, want, got)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package gameserver hosts live games of Santorini over WebSockets. Each
+// game is in a room, named in the URL, that two players sit down in and
+// anyone else can watch:
+//
+//	/rooms/NAME?seat=white|black|spectator[&token=T][&engine=white|black][&clock=5m]
+//
+// The first connection to a room creates it, and its engine and clock
+// settle whether the engine takes a seat and how long each side has for
+// the game. A seated player is given a token in every update, and coming
+// back with it puts them in their seat again, say after their connection
+// drops. The clocks start once both seats are filled. A room is deleted
+// once its game is over and everyone has gone, or after nobody has been
+// connected to it for the server's IdleTimeout.
+//
+// Players send JSON commands:
+//
+//	{"type": "move", "move": "A 6-7^2"}
+//	{"type": "move", "move_build": {"Move": 128, "Build": 4, "Ply": false, "Piece": false}}
+//	{"type": "resign"}
+//
+// and everyone in the room is sent a State after every change. Commands
+// that can't be carried out are answered with {"type": "error", ...}.
+package gameserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/Santorini"
+	"main/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPingInterval is how often a connection is pinged to check the
+	// player is still there.
+	DefaultPingInterval = 30 * time.Second
+	// DefaultMaxRooms is how many rooms a Server holds at once, unless it
+	// is given another limit.
+	DefaultMaxRooms = 1000
+	// DefaultIdleTimeout is how long a room with nobody connected to it is
+	// kept for them to come back.
+	DefaultIdleTimeout = 10 * time.Minute
+)
+
+// sendBuffer is how many messages can wait for a client before it is
+// judged too slow and disconnected.
+const sendBuffer = 16
+
+var (
+	ErrBadCommand   = errors.New("unknown command")
+	ErrTooManyRooms = errors.New("too many rooms, try again later")
+)
+
+// Server is an http.Handler that serves the rooms. Engine seats take one
+// of a fixed number of Searchers for each move, and wait in line for one
+// to be free, so rooms don't each hold a transposition table.
+type Server struct {
+	// EngineLimits bounds the engine's search for each move. In a timed
+	// game, the engine also uses no more than a tenth of its time left,
+	// which includes any wait for a searcher.
+	EngineLimits Santorini.Limits
+	// PingInterval is how often connections are pinged. One that doesn't
+	// answer with anything within two intervals is dropped.
+	PingInterval time.Duration
+	// MaxRooms is how many rooms there can be at once. Connecting to a new
+	// room beyond it is answered with ErrTooManyRooms.
+	MaxRooms int
+	// IdleTimeout is how long a room with an unfinished game is kept after
+	// the last connection to it goes.
+	IdleTimeout time.Duration
+
+	searchers chan *Santorini.Searcher
+	mu        sync.Mutex
+	rooms     map[string]*Room
+}
+
+// New makes a Server whose engine seats share engines searchers, so that
+// no more than engines of them think at once.
+func New(engines int) *Server {
+	if engines < 1 {
+		engines = 1
+	}
+	s := &Server{
+		EngineLimits: Santorini.Limits{MoveTime: time.Second},
+		PingInterval: DefaultPingInterval,
+		MaxRooms:     DefaultMaxRooms,
+		IdleTimeout:  DefaultIdleTimeout,
+		searchers:    make(chan *Santorini.Searcher, engines),
+		rooms:        map[string]*Room{},
+	}
+	for i := 0; i < engines; i++ {
+		s.searchers <- Santorini.NewSearcher()
+	}
+	return s
+}
+
+// State is what everyone in a room is sent after every change.
+type State struct {
+	Type string `json:"type"` // Always "state".
+	Room string `json:"room"`
+	// Seat is the seat of the client it is sent to, and Token the token
+	// for coming back to it.
+	Seat     string `json:"seat"`
+	Token    string `json:"token,omitempty"`
+	Position string `json:"position"`
+	// History holds the moves so far in Santorini.Numeric notation, and
+	// LegalMoves those open to the side to move while the game goes on.
+	History    []string `json:"history"`
+	LegalMoves []string `json:"legal_moves"`
+	ToMove     string   `json:"to_move"`
+	Players    Players  `json:"players"`
+	Clocks     *Clocks  `json:"clocks,omitempty"`
+	// Result is Santorini.WhiteWins, BlackWins or Unfinished, and Reason
+	// says how a finished game ended.
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Players says who is in each seat: "engine", "connected", "away" or
+// "open".
+type Players struct {
+	White string `json:"white"`
+	Black string `json:"black"`
+}
+
+// Clocks is the time each side has left, and whose clock is running.
+type Clocks struct {
+	WhiteMS int64  `json:"white_ms"`
+	BlackMS int64  `json:"black_ms"`
+	Running string `json:"running,omitempty"`
+}
+
+// command is a message from a player.
+type command struct {
+	Type      string               `json:"type"`
+	Move      string               `json:"move,omitempty"`
+	MoveBuild *Santorini.MoveBuild `json:"move_build,omitempty"`
+}
+
+type errorMessage struct {
+	Type  string `json:"type"` // Always "error".
+	Error string `json:"error"`
+}
+
+// moveBuild is the move in cmd, in either form. Whether it is legal is up
+// to Game.Play.
+func (cmd command) moveBuild(p Santorini.Position) (Santorini.MoveBuild, error) {
+	if cmd.MoveBuild != nil {
+		return *cmd.MoveBuild, nil
+	}
+	return Santorini.ParseMove(p, cmd.Move)
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseSeat reads a seat name. No name at all is a spectator.
+func parseSeat(name string) (int, error) {
+	if name == "" {
+		return spectator, nil
+	}
+	for seat, n := range seatNames {
+		if n == name {
+			return seat, nil
+		}
+	}
+	return spectator, fmt.Errorf("unknown seat %q", name)
+}
+
+// room finds the room called name, or makes it with the engine playing
+// seat engine, if it isn't spectator, and clock on each side's clock.
+func (s *Server) room(name string, engine int, clock time.Duration) (*Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.rooms[name]
+	if !ok {
+		if len(s.rooms) >= s.MaxRooms {
+			return nil, ErrTooManyRooms
+		}
+		r = newRoom(s, name, engine, clock)
+		s.rooms[name] = r
+	}
+	return r, nil
+}
+
+// remove deletes r, unless it has already been replaced.
+func (s *Server) remove(r *Room) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rooms[r.name] == r {
+		delete(s.rooms, r.name)
+	}
+}
+
+// Room returns the room called name, or nil if there isn't one.
+func (s *Server) Room(name string) *Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rooms[name]
+}
+
+// Game returns a copy of the room's game so far.
+func (r *Room) Game() *Santorini.Game {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g := &Santorini.Game{Tags: map[string]string{}, Start: r.game.Start}
+	for k, v := range r.game.Tags {
+		g.Tags[k] = v
+	}
+	g.Moves = append(g.Moves, r.game.Moves...)
+	return g
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/rooms/")
+	if name == req.URL.Path || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, req)
+		return
+	}
+	q := req.URL.Query()
+	seat, err := parseSeat(q.Get("seat"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	engine := spectator
+	if q.Has("engine") {
+		if engine, err = parseSeat(q.Get("engine")); err != nil || engine == spectator {
+			http.Error(w, "engine must be white or black", http.StatusBadRequest)
+			return
+		}
+	}
+	var clock time.Duration
+	if q.Has("clock") {
+		if clock, err = time.ParseDuration(q.Get("clock")); err != nil || clock <= 0 {
+			http.Error(w, "clock must be a positive duration, such as 5m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, req)
+	if err != nil {
+		return
+	}
+	conn.MaxMessageSize = 1 << 12
+	conn.ReadTimeout = 2 * s.PingInterval
+	c := &client{conn: conn, send: make(chan []byte, sendBuffer), done: make(chan struct{}), seat: spectator}
+	go c.writeLoop(s.PingInterval)
+	defer c.close()
+
+	var r *Room
+	for {
+		if r, err = s.room(name, engine, clock); err != nil {
+			break
+		}
+		// A room deleted since it was looked up is made afresh.
+		if err = r.join(c, seat, q.Get("token")); err != errRoomGone {
+			break
+		}
+	}
+	if err != nil {
+		c.sendJSON(errorMessage{"error", err.Error()})
+		return
+	}
+	defer r.leave(c)
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd command
+		if err := json.Unmarshal(msg, &cmd); err != nil {
+			c.sendJSON(errorMessage{"error", fmt.Sprintf("bad command: %v", err)})
+			continue
+		}
+		switch cmd.Type {
+		case "move":
+			err = r.move(c, cmd)
+		case "resign":
+			err = r.resign(c)
+		default:
+			err = fmt.Errorf("%w %q", ErrBadCommand, cmd.Type)
+		}
+		if err != nil {
+			c.sendJSON(errorMessage{"error", err.Error()})
+		}
+	}
+}
+
+// client is one connection to a room.
+type client struct {
+	conn  *websocket.Conn
+	send  chan []byte
+	done  chan struct{} // Closed to have writeLoop close the connection.
+	once  sync.Once
+	seat  int
+	token string
+}
+
+// sendJSON queues v to be sent. A client too slow to keep up is dropped,
+// rather than hold up the room.
+func (c *client) sendJSON(v interface{}) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		c.close()
+	}
+}
+
+// writeLoop sends queued messages and pings until the client is closed,
+// then sends what is still queued, such as the reason for closing, and
+// closes the connection.
+func (c *client) writeLoop(ping time.Duration) {
+	ticker := time.NewTicker(ping)
+	defer ticker.Stop()
+	defer c.conn.Close()
+	for {
+		var err error
+		select {
+		case msg := <-c.send:
+			err = c.conn.WriteMessage(msg)
+		case <-ticker.C:
+			err = c.conn.Ping()
+		case <-c.done:
+			for {
+				select {
+				case msg := <-c.send:
+					if c.conn.WriteMessage(msg) != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+		if err != nil {
+			c.close()
+			return
+		}
+	}
+}
+
+// close tells writeLoop to finish up and close the connection.
+func (c *client) close() {
+	c.once.Do(func() { close(c.done) })
+}
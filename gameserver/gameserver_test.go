@@ -0,0 +1,246 @@
+package gameserver
+
+import (
+	"encoding/json"
+	"main/Santorini"
+	"main/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// message is anything the server sends.
+type message struct {
+	State
+	Error string `json:"error"`
+}
+
+// player is a test client in a room.
+type player struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s := New(1)
+	s.EngineLimits = Santorini.Limits{Depth: 1}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func join(t *testing.T, ts *httptest.Server, path string) *player {
+	t.Helper()
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(ts.URL, "http") + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.ReadTimeout = 5 * time.Second
+	t.Cleanup(func() { conn.Close() })
+	return &player{t, conn}
+}
+
+// next reads the next message.
+func (p *player) next() message {
+	p.t.Helper()
+	data, err := p.conn.ReadMessage()
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		p.t.Fatalf("can't decode %q: %v", data, err)
+	}
+	return m
+}
+
+// until reads messages until one satisfies ok.
+func (p *player) until(ok func(m message) bool) message {
+	p.t.Helper()
+	for {
+		if m := p.next(); ok(m) {
+			return m
+		}
+	}
+}
+
+// moves waits for a state with n moves played.
+func (p *player) moves(n int) message {
+	p.t.Helper()
+	return p.until(func(m message) bool { return m.Type == "state" && len(m.History) == n })
+}
+
+func (p *player) send(cmd string) {
+	p.t.Helper()
+	if err := p.conn.WriteMessage([]byte(cmd)); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// expectError reads messages until an error, and checks it is want.
+func (p *player) expectError(want error) {
+	p.t.Helper()
+	m := p.until(func(m message) bool { return m.Type == "error" })
+	if m.Error != want.Error() {
+		p.t.Fatalf("expected %q, got %q", want, m.Error)
+	}
+}
+
+func TestTwoPlayers(t *testing.T) {
+	_, ts := newTestServer(t)
+	white := join(t, ts, "/rooms/one?seat=white")
+	if m := white.next(); m.Seat != "white" || m.Token == "" || m.Players != (Players{"connected", "open"}) || m.Clocks != nil {
+		t.Fatalf("unexpected first state %+v", m)
+	}
+	black := join(t, ts, "/rooms/one?seat=black")
+	if m := white.next(); m.Players != (Players{"connected", "connected"}) || len(m.LegalMoves) != 300 {
+		t.Fatalf("expected both players, got %+v", m)
+	}
+	black.next()
+
+	black.send(`{"type": "move", "move": "X 8 Y 16"}`)
+	black.expectError(ErrNotYourTurn)
+	white.send(`{"type": "move", "move": "A 6 B 12"}`)
+	if m := black.moves(1); m.History[0] != "A 6 B 12" || m.ToMove != "black" || m.Seat != "black" {
+		t.Fatalf("unexpected state %+v", m)
+	}
+	white.moves(1)
+
+	// Moves can come as a MoveBuild too, and are checked all the same.
+	black.send(`{"type": "move", "move_build": {"Move": 4, "Ply": true}}`)
+	black.expectError(Santorini.ErrIllegalMove)
+	black.send(`{"type": "move", "move_build": {"Move": 65792, "Ply": true}}`)
+	m := white.moves(2)
+	if m.History[1] != "X 8 Y 16" || m.Position != "|0000000000000000000000000|06120816|w|2|" {
+		t.Fatalf("unexpected state %+v", m)
+	}
+
+	watcher := join(t, ts, "/rooms/one")
+	if m := watcher.next(); m.Seat != "spectator" || m.Token != "" || len(m.History) != 2 {
+		t.Fatalf("expected the spectator to see the game so far, got %+v", m)
+	}
+	watcher.send(`{"type": "move", "move": "A 6-7^2"}`)
+	watcher.expectError(ErrNotSeated)
+	white.send(`{"type": "dance"}`)
+	white.until(func(m message) bool { return m.Error == `unknown command "dance"` })
+	white.send(`{"type": "move", "move": "A 6-7^2"}`)
+	if m := watcher.moves(3); m.History[2] != "A 6-7^2" {
+		t.Fatalf("expected the spectator to see the move, got %+v", m)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	s, ts := newTestServer(t)
+	white := join(t, ts, "/rooms/two?seat=white")
+	token := white.next().Token
+	black := join(t, ts, "/rooms/two?seat=black")
+	black.next()
+	white.send(`{"type": "move", "move": "A 6 B 12"}`)
+	black.moves(1)
+
+	white.conn.Close()
+	if m := black.until(func(m message) bool { return m.Players.White == "away" }); len(m.History) != 1 {
+		t.Fatalf("unexpected state %+v", m)
+	}
+	stranger := join(t, ts, "/rooms/two?seat=white&token=guess")
+	stranger.expectError(ErrSeatTaken)
+
+	white = join(t, ts, "/rooms/two?seat=white&token="+token)
+	if m := white.next(); m.Seat != "white" || m.Token != token || len(m.History) != 1 {
+		t.Fatalf("expected white back in their seat, got %+v", m)
+	}
+	black.until(func(m message) bool { return m.Players.White == "connected" })
+
+	// Coming back on a second connection takes the seat from the first.
+	again := join(t, ts, "/rooms/two?seat=white&token="+token)
+	again.next()
+	for {
+		if _, err := white.conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	black.send(`{"type": "move", "move": "X 8 Y 16"}`)
+	again.moves(2)
+	if g := s.Room("two").Game(); len(g.Moves) != 2 || g.Tags["Event"] != "two" {
+		t.Fatalf("unexpected game %+v", g)
+	}
+}
+
+func TestBadRooms(t *testing.T) {
+	_, ts := newTestServer(t)
+	for path, want := range map[string]int{
+		"/rooms/x?seat=red":         http.StatusBadRequest,
+		"/rooms/x?engine=spectator": http.StatusBadRequest,
+		"/rooms/x?clock=soon":       http.StatusBadRequest,
+		"/rooms/x?clock=-1s":        http.StatusBadRequest,
+		"/rooms/":                   http.StatusNotFound,
+		"/rooms/x/y":                http.StatusNotFound,
+		"/elsewhere":                http.StatusNotFound,
+		"/rooms/x?seat=white":       http.StatusBadRequest, // Not a WebSocket handshake.
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%v: expected %v, got %v", path, want, resp.Status)
+		}
+	}
+}
+
+// waitGone waits for the room called name to be deleted.
+func waitGone(t *testing.T, s *Server, name string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); s.Room(name) != nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected room %v to be deleted", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRoomsDeleted(t *testing.T) {
+	s, ts := newTestServer(t)
+	s.IdleTimeout = 100 * time.Millisecond
+
+	// A finished game goes once everyone has left.
+	white := join(t, ts, "/rooms/six?seat=white")
+	white.next()
+	watcher := join(t, ts, "/rooms/six")
+	watcher.next()
+	white.send(`{"type": "resign"}`)
+	white.until(func(m message) bool { return m.Result != Santorini.Unfinished })
+	white.conn.Close()
+	time.Sleep(2 * s.IdleTimeout)
+	if s.Room("six") == nil {
+		t.Fatalf("expected the room to stay while the spectator watches")
+	}
+	watcher.conn.Close()
+	waitGone(t, s, "six")
+
+	// An unfinished game goes once nobody has come back for IdleTimeout,
+	// and the name can be used again.
+	white = join(t, ts, "/rooms/seven?seat=white")
+	token := white.next().Token
+	white.conn.Close()
+	waitGone(t, s, "seven")
+	stranger := join(t, ts, "/rooms/seven?seat=white")
+	if m := stranger.next(); m.Token == token || m.Seat != "white" {
+		t.Fatalf("expected a new room, got %+v", m)
+	}
+}
+
+func TestTooManyRooms(t *testing.T) {
+	s, ts := newTestServer(t)
+	s.MaxRooms = 1
+	white := join(t, ts, "/rooms/eight?seat=white")
+	white.next()
+	join(t, ts, "/rooms/nine?seat=white").expectError(ErrTooManyRooms)
+	if m := join(t, ts, "/rooms/eight").next(); m.Seat != "spectator" {
+		t.Fatalf("expected to watch the room there is, got %+v", m)
+	}
+}
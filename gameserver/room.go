@@ -0,0 +1,425 @@
+package gameserver
+
+import (
+	"context"
+	"errors"
+	"main/Santorini"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSeatTaken   = errors.New("that seat is taken")
+	ErrEngineSeat  = errors.New("the engine plays that seat")
+	ErrNotSeated   = errors.New("spectators can't play")
+	ErrNotYourTurn = errors.New("it isn't your turn")
+	ErrGameOver    = errors.New("the game is over")
+
+	// errRoomGone is join's answer for a room deleted since it was looked
+	// up.
+	errRoomGone = errors.New("the room has been deleted")
+)
+
+// Seats, as indexes into Room.seats: the side's Ply as a number.
+const (
+	white     = 0
+	black     = 1
+	spectator = -1
+)
+
+var seatNames = map[int]string{white: "white", black: "black", spectator: "spectator"}
+
+// seat is a player's place in a room. The token stays when the player's
+// connection drops, so they can come back to it.
+type seat struct {
+	token  string
+	client *client // Nil while the player is away.
+}
+
+// Room is one game and everyone watching it.
+type Room struct {
+	name   string
+	server *Server
+
+	mu         sync.Mutex
+	game       *Santorini.Game
+	seats      [2]seat
+	spectators map[*client]bool
+
+	// engine is the seat the engine plays, or spectator for none.
+	engine int
+	cancel context.CancelFunc // Stops the engine's search, when it has one.
+
+	// idle deletes the room, while nobody is connected to it. gone is set
+	// once it has been deleted.
+	idle *time.Timer
+	gone bool
+
+	// Clocks, when timed is set, hold the time each side has left as of
+	// turnStart. They start once both seats are filled.
+	timed     bool
+	clocks    [2]time.Duration
+	started   bool
+	turnStart time.Time
+	flag      *time.Timer
+}
+
+func newRoom(s *Server, name string, engine int, clock time.Duration) *Room {
+	r := &Room{
+		name:       name,
+		server:     s,
+		game:       Santorini.NewGameRecord(),
+		spectators: map[*client]bool{},
+		engine:     engine,
+		timed:      clock > 0,
+		clocks:     [2]time.Duration{clock, clock},
+	}
+	r.game.Tags["Event"] = name
+	r.game.Tags["Date"] = time.Now().Format("2006.01.02")
+	if engine != spectator {
+		r.game.Tags[[2]string{"White", "Black"}[engine]] = "engine"
+	}
+	// Nobody is connected yet, so the idle timer starts now.
+	r.tidy()
+	return r
+}
+
+// toMove is the seat of the side to move.
+func (r *Room) toMove() int {
+	if r.game.Position().Ply {
+		return black
+	}
+	return white
+}
+
+func (r *Room) over() bool {
+	return r.game.Result() != Santorini.Unfinished
+}
+
+// join seats c, or adds it to the spectators. A player can take back a
+// seat they left with the token they were given for it, and a new
+// connection with the token takes the seat from an old one.
+func (r *Room) join(c *client, side int, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gone {
+		return errRoomGone
+	}
+	if side == spectator {
+		c.seat = spectator
+		r.spectators[c] = true
+		r.tidy()
+		r.broadcast()
+		return nil
+	}
+	if side == r.engine {
+		return ErrEngineSeat
+	}
+	s := &r.seats[side]
+	switch {
+	case s.token == "":
+		s.token = newToken()
+	case token != s.token:
+		return ErrSeatTaken
+	case s.client != nil:
+		s.client.close()
+	}
+	s.client = c
+	c.seat, c.token = side, s.token
+	r.tidy()
+	r.start()
+	r.broadcast()
+	r.engineMove()
+	return nil
+}
+
+// leave takes c out of the room. A player's seat is kept for them.
+func (r *Room) leave(c *client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c.seat == spectator {
+		delete(r.spectators, c)
+	} else if r.seats[c.seat].client == c {
+		r.seats[c.seat].client = nil
+		r.broadcast()
+	}
+	r.tidy()
+}
+
+// connected reports whether anyone is connected to the room.
+func (r *Room) connected() bool {
+	return r.seats[white].client != nil || r.seats[black].client != nil || len(r.spectators) > 0
+}
+
+// tidy deletes the room once its game is over and nobody is connected to
+// it. While the game goes on, it keeps an empty room for IdleTimeout in
+// case the players come back.
+func (r *Room) tidy() {
+	if r.gone {
+		return
+	}
+	if r.connected() {
+		if r.idle != nil {
+			r.idle.Stop()
+			r.idle = nil
+		}
+		return
+	}
+	if r.over() {
+		r.remove()
+		return
+	}
+	if r.idle == nil {
+		var idle *time.Timer
+		idle = time.AfterFunc(r.server.IdleTimeout, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			// A timer stopped too late to stop it running is no longer
+			// r.idle.
+			if r.idle == idle {
+				r.remove()
+			}
+		})
+		r.idle = idle
+	}
+}
+
+// remove deletes the room from the server, and stops its clocks and
+// engine.
+func (r *Room) remove() {
+	r.gone = true
+	if r.idle != nil {
+		r.idle.Stop()
+		r.idle = nil
+	}
+	if r.flag != nil {
+		r.flag.Stop()
+	}
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.server.remove(r)
+}
+
+// filled reports whether side has a player or the engine.
+func (r *Room) filled(side int) bool {
+	return side == r.engine || r.seats[side].token != ""
+}
+
+// start starts the clocks, once both seats are filled.
+func (r *Room) start() {
+	if r.started || !r.filled(white) || !r.filled(black) {
+		return
+	}
+	r.started = true
+	r.turnStart = time.Now()
+	r.setFlag()
+}
+
+// setFlag sets a timer for the side to move running out of time.
+func (r *Room) setFlag() {
+	if r.flag != nil {
+		r.flag.Stop()
+	}
+	if !r.timed || r.over() {
+		return
+	}
+	moves := len(r.game.Moves)
+	r.flag = time.AfterFunc(r.clocks[r.toMove()], func() { r.flagFell(moves) })
+}
+
+// flagFell ends the game on time, if the side to move still hasn't moved
+// since moves were played.
+func (r *Room) flagFell(moves int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.game.Moves) != moves || r.over() {
+		return
+	}
+	side := r.toMove()
+	r.clocks[side] = 0
+	r.finish(1-side, "time forfeit")
+	r.broadcast()
+	r.tidy()
+}
+
+// finish ends the game early with a win for winner.
+func (r *Room) finish(winner int, termination string) {
+	r.game.Tags["Result"] = [2]string{Santorini.WhiteWins, Santorini.BlackWins}[winner]
+	r.game.Tags["Termination"] = termination
+	if r.flag != nil {
+		r.flag.Stop()
+	}
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// play plays mb for the side to move, who has used up the time since
+// their turn started. A move that comes after their time ran out, but
+// before flagFell got to it, loses on time all the same.
+func (r *Room) play(mb Santorini.MoveBuild) error {
+	if r.over() {
+		return ErrGameOver
+	}
+	now := time.Now()
+	mover := r.toMove()
+	if r.timed && r.started && r.clocks[mover]-now.Sub(r.turnStart) <= 0 {
+		r.clocks[mover] = 0
+		r.finish(1-mover, "time forfeit")
+		r.broadcast()
+		r.tidy()
+		return ErrGameOver
+	}
+	if err := r.game.Play(mb); err != nil {
+		return err
+	}
+	if r.started {
+		r.clocks[mover] -= now.Sub(r.turnStart)
+		r.turnStart = now
+	}
+	r.setFlag()
+	r.broadcast()
+	r.tidy()
+	r.engineMove()
+	return nil
+}
+
+// move plays a move sent by c.
+func (r *Room) move(c *client, cmd command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case c.seat == spectator:
+		return ErrNotSeated
+	case r.over():
+		return ErrGameOver
+	case c.seat != r.toMove():
+		return ErrNotYourTurn
+	}
+	mb, err := cmd.moveBuild(r.game.Position())
+	if err != nil {
+		return err
+	}
+	return r.play(mb)
+}
+
+// resign ends the game with a win for c's opponent.
+func (r *Room) resign(c *client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case c.seat == spectator:
+		return ErrNotSeated
+	case r.over():
+		return ErrGameOver
+	}
+	r.finish(1-c.seat, "resignation")
+	r.broadcast()
+	return nil
+}
+
+// engineMove starts the engine thinking, if it is its turn, once it has
+// one of the server's searchers.
+func (r *Room) engineMove() {
+	if r.engine != r.toMove() || !r.started || r.over() || r.gone || r.cancel != nil {
+		return
+	}
+	p := r.game.Position()
+	moves := len(r.game.Moves)
+	limits := r.server.EngineLimits
+	if r.timed && (limits.MoveTime == 0 || limits.MoveTime > r.clocks[r.engine]/10) {
+		// Leave time for the rest of the game.
+		limits.MoveTime = r.clocks[r.engine] / 10
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go func() {
+		var result Santorini.SearchResult
+		select {
+		case searcher := <-r.server.searchers:
+			result = searcher.IterativeSearch(ctx, p, limits, nil)
+			r.server.searchers <- searcher
+		case <-ctx.Done():
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		cancel()
+		r.cancel = nil
+		if len(r.game.Moves) != moves || r.over() || r.gone {
+			return
+		}
+		r.play(result.Move)
+	}()
+}
+
+// broadcast sends everyone the state of the room.
+func (r *Room) broadcast() {
+	if c := r.seats[white].client; c != nil {
+		c.sendJSON(r.state(c))
+	}
+	if c := r.seats[black].client; c != nil {
+		c.sendJSON(r.state(c))
+	}
+	for c := range r.spectators {
+		c.sendJSON(r.state(c))
+	}
+}
+
+// state describes the room to c.
+func (r *Room) state(c *client) State {
+	p := r.game.Start
+	history := []string{}
+	for _, mb := range r.game.Moves {
+		history = append(history, Santorini.Numeric.Format(p, mb))
+		p = Santorini.UpdatePosition(p, mb)
+	}
+	legal := []string{}
+	if !r.over() {
+		for _, mb := range Santorini.LegalBuildMoves(p) {
+			legal = append(legal, Santorini.Numeric.Format(p, mb))
+		}
+	}
+	s := State{
+		Type:       "state",
+		Room:       r.name,
+		Seat:       seatNames[c.seat],
+		Token:      c.token,
+		Position:   p.String(),
+		History:    history,
+		LegalMoves: legal,
+		ToMove:     seatNames[r.toMove()],
+		Players:    Players{White: r.player(white), Black: r.player(black)},
+		Result:     r.game.Result(),
+	}
+	if ended := r.game.Ended(); ended.Over() {
+		s.Reason = ended.String()
+	} else if r.over() {
+		s.Reason = r.game.Tags["Termination"]
+	}
+	if r.timed {
+		// The side to move's clock runs down between moves.
+		clocks := r.clocks
+		s.Clocks = &Clocks{}
+		if r.started && !r.over() {
+			clocks[r.toMove()] -= time.Since(r.turnStart)
+			s.Clocks.Running = seatNames[r.toMove()]
+		}
+		s.Clocks.WhiteMS, s.Clocks.BlackMS = clocks[white].Milliseconds(), clocks[black].Milliseconds()
+	}
+	return s
+}
+
+// player says who is in seat side: the engine, a player who is connected
+// or away, or nobody yet.
+func (r *Room) player(side int) string {
+	switch {
+	case side == r.engine:
+		return "engine"
+	case r.seats[side].client != nil:
+		return "connected"
+	case r.seats[side].token != "":
+		return "away"
+	}
+	return "open"
+}
@@ -0,0 +1,77 @@
+package gameserver
+
+import (
+	"main/Santorini"
+	"testing"
+	"time"
+)
+
+func TestEngineSeat(t *testing.T) {
+	_, ts := newTestServer(t)
+	white := join(t, ts, "/rooms/three?seat=white&engine=black")
+	if m := white.next(); m.Players != (Players{"connected", "engine"}) {
+		t.Fatalf("expected the engine in black's seat, got %+v", m)
+	}
+	join(t, ts, "/rooms/three?seat=black").expectError(ErrEngineSeat)
+	white.send(`{"type": "move", "move": "A 6 B 12"}`)
+	if m := white.moves(2); m.ToMove != "white" {
+		t.Fatalf("expected the engine to have replied, got %+v", m)
+	}
+
+	// Engines in other rooms share the server's one searcher.
+	black := join(t, ts, "/rooms/three-b?seat=black&engine=white")
+	if m := black.moves(1); m.ToMove != "black" {
+		t.Fatalf("expected the engine to have placed, got %+v", m)
+	}
+	white.send(`{"type": "move", "move": "A 6-7^2"}`)
+	white.moves(4)
+}
+
+func TestClocks(t *testing.T) {
+	_, ts := newTestServer(t)
+	white := join(t, ts, "/rooms/four?seat=white&clock=200ms")
+	if m := white.next(); m.Clocks == nil || m.Clocks.WhiteMS != 200 || m.Clocks.Running != "" {
+		t.Fatalf("expected stopped clocks, got %+v", m.Clocks)
+	}
+	black := join(t, ts, "/rooms/four?seat=black")
+	black.next()
+	white.send(`{"type": "move", "move": "A 6 B 12"}`)
+	m := black.moves(1)
+	if m.Clocks.Running != "black" || m.Clocks.WhiteMS > 200 || m.Clocks.BlackMS > 200 {
+		t.Fatalf("expected black's clock to be running, got %+v", m.Clocks)
+	}
+	// Black doesn't move, and loses on time.
+	m = white.until(func(m message) bool { return m.Result != Santorini.Unfinished })
+	if m.Result != Santorini.WhiteWins || m.Reason != "time forfeit" || m.Clocks.BlackMS != 0 || m.Clocks.Running != "" {
+		t.Fatalf("expected white to win on time, got %+v", m)
+	}
+	black.send(`{"type": "move", "move": "X 8 Y 16"}`)
+	black.expectError(ErrGameOver)
+}
+
+func TestLateMove(t *testing.T) {
+	// White's time runs out just before their move comes in, and before
+	// the flag timer takes the room.
+	r := newRoom(New(1), "six", spectator, time.Second)
+	r.started = true
+	r.turnStart = time.Now().Add(-2 * time.Second)
+	if err := r.play(Santorini.LegalBuildMoves(r.game.Start)[0]); err != ErrGameOver {
+		t.Fatalf("expected %v, got %v", ErrGameOver, err)
+	}
+	if len(r.game.Moves) != 0 || r.clocks[white] != 0 || r.game.Tags["Result"] != Santorini.BlackWins || r.game.Tags["Termination"] != "time forfeit" {
+		t.Fatalf("expected white to lose on time without moving, got %+v %v", r.game, r.clocks)
+	}
+}
+
+func TestResign(t *testing.T) {
+	_, ts := newTestServer(t)
+	white := join(t, ts, "/rooms/five?seat=white")
+	white.next()
+	white.send(`{"type": "resign"}`)
+	m := white.until(func(m message) bool { return m.Result != Santorini.Unfinished })
+	if m.Result != Santorini.BlackWins || m.Reason != "resignation" || len(m.LegalMoves) != 0 {
+		t.Fatalf("expected black to win, got %+v", m)
+	}
+	white.send(`{"type": "resign"}`)
+	white.expectError(ErrGameOver)
+}
@@ -1,3 +1,3 @@
 module main
 
-go 1.16
+go 1.21
@@ -0,0 +1,299 @@
+// Package server answers questions about Santorini positions over HTTP,
+// with JSON in and out, for web front ends that would rather not run the
+// engine themselves.
+//
+// Every endpoint takes a POST with a JSON object holding a position, in the
+// form Santorini.NewPosition reads, or empty for the start of a game:
+//
+//	/moves     the legal moves, in Santorini.Numeric notation
+//	/play      the position after a move, given as "move"
+//	/evaluate  the engine's move and score, with an optional "depth",
+//	           "movetime_ms" and "nodes" to bound the search
+//	/outcome   whether the game is over, and who won
+//
+// Problems come back with a 4xx or 5xx status and a JSON object holding
+// "error".
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/Santorini"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxMoveTime is the longest an evaluation may search for,
+	// unless the Server is given another limit.
+	DefaultMaxMoveTime = 5 * time.Second
+	// DefaultQueueTimeout is how long an evaluation waits for a searcher
+	// before giving up.
+	DefaultQueueTimeout = 5 * time.Second
+	// maxBody is the most a request body may hold.
+	maxBody = 1 << 16
+)
+
+var ErrBusy = errors.New("too many searches running, try again later")
+
+// Server is an http.Handler for the endpoints. Evaluations each take one
+// of a fixed number of Searchers, which also limits how many run at once,
+// and requests wait in line for one to be free.
+type Server struct {
+	// MaxMoveTime caps the time an evaluation searches for. It is also how
+	// long an evaluation with no other limit searches.
+	MaxMoveTime time.Duration
+	// QueueTimeout is how long an evaluation waits for a free searcher
+	// before the server answers 503 Service Unavailable.
+	QueueTimeout time.Duration
+
+	searchers chan *Santorini.Searcher
+	mux       *http.ServeMux
+}
+
+// New makes a Server that runs up to searches evaluations at once, each
+// on threads threads.
+func New(searches, threads int) *Server {
+	if searches < 1 {
+		searches = 1
+	}
+	s := &Server{
+		MaxMoveTime:  DefaultMaxMoveTime,
+		QueueTimeout: DefaultQueueTimeout,
+		searchers:    make(chan *Santorini.Searcher, searches),
+		mux:          http.NewServeMux(),
+	}
+	for i := 0; i < searches; i++ {
+		searcher := Santorini.NewSearcher()
+		searcher.Threads = threads
+		s.searchers <- searcher
+	}
+	s.mux.HandleFunc("/moves", post(s.moves))
+	s.mux.HandleFunc("/play", post(s.play))
+	s.mux.HandleFunc("/evaluate", post(s.evaluate))
+	s.mux.HandleFunc("/outcome", post(s.outcome))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Request is the body of a request to any endpoint. Each uses only the
+// fields it needs.
+type Request struct {
+	Position   string `json:"position"`
+	Move       string `json:"move,omitempty"`
+	Depth      int    `json:"depth,omitempty"`
+	MoveTimeMS int    `json:"movetime_ms,omitempty"`
+	Nodes      int    `json:"nodes,omitempty"`
+}
+
+// MovesResponse answers /moves.
+type MovesResponse struct {
+	Position string   `json:"position"`
+	Moves    []string `json:"moves"`
+}
+
+// PlayResponse answers /play. Outcome is that of the move if it won, and
+// otherwise that of the new position.
+type PlayResponse struct {
+	Position string          `json:"position"`
+	Outcome  OutcomeResponse `json:"outcome"`
+}
+
+// EvaluateResponse answers /evaluate. Move is empty when the side to move
+// has no move.
+type EvaluateResponse struct {
+	Move      string   `json:"move"`
+	Score     int      `json:"score"`
+	PV        []string `json:"pv"`
+	Depth     int      `json:"depth"`
+	Nodes     int      `json:"nodes"`
+	ElapsedMS int64    `json:"elapsed_ms"`
+}
+
+// OutcomeResponse answers /outcome. Outcome is Santorini.Position's
+// Outcome: "W" or "B" when the game is won, or the side to move can win
+// with a climb, and "?" otherwise.
+type OutcomeResponse struct {
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason"`
+	Text    string `json:"text"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with the status to answer it with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(err error) error {
+	return &httpError{http.StatusBadRequest, err}
+}
+
+// post adapts an endpoint to an http.HandlerFunc that only takes POSTs,
+// decodes the request and encodes the answer.
+func post(endpoint func(ctx context.Context, req Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"only POST is allowed"})
+			return
+		}
+		var req Request
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{fmt.Sprintf("bad request body: %v", err)})
+			return
+		}
+		resp, err := endpoint(r.Context(), req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var he *httpError
+			if errors.As(err, &he) {
+				status = he.status
+			}
+			writeJSON(w, status, errorResponse{err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// position reads the position in req, the start of a game if it is empty.
+func position(req Request) (Santorini.Position, error) {
+	if req.Position == "" {
+		return Santorini.NewGame(), nil
+	}
+	p, err := Santorini.NewPosition(req.Position)
+	if err != nil {
+		return p, badRequest(err)
+	}
+	return p, nil
+}
+
+func (s *Server) moves(ctx context.Context, req Request) (interface{}, error) {
+	p, err := position(req)
+	if err != nil {
+		return nil, err
+	}
+	moves := []string{}
+	for _, mb := range Santorini.LegalBuildMoves(p) {
+		moves = append(moves, Santorini.Numeric.Format(p, mb))
+	}
+	return MovesResponse{Position: p.String(), Moves: moves}, nil
+}
+
+func (s *Server) play(ctx context.Context, req Request) (interface{}, error) {
+	p, err := position(req)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := Santorini.ParseMove(p, req.Move)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	// A climb ends the game, whatever the position after it looks like.
+	result := p.ResultAfter(mb)
+	p = Santorini.UpdatePosition(p, mb)
+	if !result.Over() {
+		result = p.Result()
+	}
+	return PlayResponse{Position: p.String(), Outcome: outcomeOf(result)}, nil
+}
+
+func (s *Server) outcome(ctx context.Context, req Request) (interface{}, error) {
+	p, err := position(req)
+	if err != nil {
+		return nil, err
+	}
+	return outcomeOf(p.Result()), nil
+}
+
+// outcomeOf describes r as Outcome does.
+func outcomeOf(r Santorini.Result) OutcomeResponse {
+	o := OutcomeResponse{Outcome: "?", Reason: r.Reason.String(), Text: r.String()}
+	switch {
+	case !r.Over():
+	case r.Winner:
+		o.Outcome = "B"
+	default:
+		o.Outcome = "W"
+	}
+	return o
+}
+
+// limits turns the bounds in req into Limits no looser than the server's.
+func (s *Server) limits(req Request) (Santorini.Limits, error) {
+	if req.Depth < 0 || req.MoveTimeMS < 0 || req.Nodes < 0 {
+		return Santorini.Limits{}, badRequest(errors.New("limits can't be negative"))
+	}
+	limits := Santorini.Limits{
+		Depth:    req.Depth,
+		Nodes:    req.Nodes,
+		MoveTime: time.Duration(req.MoveTimeMS) * time.Millisecond,
+	}
+	if limits.MoveTime == 0 || limits.MoveTime > s.MaxMoveTime {
+		limits.MoveTime = s.MaxMoveTime
+	}
+	return limits, nil
+}
+
+func (s *Server) evaluate(ctx context.Context, req Request) (interface{}, error) {
+	p, err := position(req)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.limits(req)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := time.NewTimer(s.QueueTimeout)
+	defer queue.Stop()
+	var searcher *Santorini.Searcher
+	select {
+	case searcher = <-s.searchers:
+	case <-queue.C:
+		return nil, &httpError{http.StatusServiceUnavailable, ErrBusy}
+	case <-ctx.Done():
+		return nil, &httpError{http.StatusServiceUnavailable, ctx.Err()}
+	}
+	defer func() { s.searchers <- searcher }()
+
+	// The search stops early if the client goes away.
+	r := searcher.IterativeSearch(ctx, p, limits, nil)
+	resp := EvaluateResponse{
+		Score:     r.Score,
+		PV:        []string{},
+		Depth:     r.Depth,
+		Nodes:     r.Nodes,
+		ElapsedMS: r.Stats.Elapsed.Milliseconds(),
+	}
+	if r.Move.Move != 0 {
+		resp.Move = Santorini.Numeric.Format(p, r.Move)
+	}
+	for _, mb := range r.PV {
+		resp.PV = append(resp.PV, Santorini.Numeric.Format(p, mb))
+		p = Santorini.UpdatePosition(p, mb)
+	}
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// call posts body to path on s and decodes the answer into resp.
+func call(t *testing.T, s http.Handler, path, body string, resp interface{}) int {
+	t.Helper()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	r, err := http.Post(ts.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("%v: expected JSON, got %q", path, ct)
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		t.Fatalf("%v: can't decode %q: %v", path, data, err)
+	}
+	return r.StatusCode
+}
+
+func TestMoves(t *testing.T) {
+	s := New(1, 1)
+	var resp MovesResponse
+	if status := call(t, s, "/moves", `{"position": ""}`, &resp); status != http.StatusOK {
+		t.Fatalf("unexpected status %v", status)
+	}
+	// Any two of the 25 squares.
+	if len(resp.Moves) != 300 || resp.Moves[0] != "A 0 B 1" {
+		t.Fatalf("expected 300 placements, got %v", resp.Moves)
+	}
+
+	// White's workers on 0 and 1 are walled in by domes.
+	resp = MovesResponse{}
+	if call(t, s, "/moves", `{"position": "|0040044400000000000000000|00012324|w|"}`, &resp); resp.Moves == nil || len(resp.Moves) != 0 {
+		t.Fatalf("expected an empty list of moves, got %#v", resp.Moves)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	s := New(1, 1)
+	var resp PlayResponse
+	status := call(t, s, "/play", `{"position": "|0000000000000000000000000|07121117|w|", "move": "B 12-13^14"}`, &resp)
+	if status != http.StatusOK || resp.Position != "|0000000000000010000000000|07131117|b|" || resp.Outcome.Outcome != "?" {
+		t.Fatalf("unexpected answer %v %+v", status, resp)
+	}
+
+	// Black climbs from 17 onto 12 and wins.
+	resp = PlayResponse{}
+	call(t, s, "/play", `{"position": "|1002000100443440022100001|01081723|", "move": "X 17-12"}`, &resp)
+	if resp.Outcome.Outcome != "B" || resp.Outcome.Reason != "climbed to level 3" {
+		t.Fatalf("expected Black to win, got %+v", resp)
+	}
+
+	var e errorResponse
+	if status := call(t, s, "/play", `{"move": "A 6-7^2"}`, &e); status != http.StatusBadRequest || !strings.Contains(e.Error, "bad move") {
+		t.Fatalf("expected a bad move, got %v %+v", status, e)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	s := New(1, 1)
+	for position, want := range map[string]string{
+		"":                                       "?",
+		"|1002000100443440022100001|01081723|":   "B",
+		"|0040044400000000000000000|00012324|w|": "B",
+	} {
+		var resp OutcomeResponse
+		call(t, s, "/outcome", `{"position": "`+position+`"}`, &resp)
+		if resp.Outcome != want {
+			t.Fatalf("%q: expected %v, got %+v", position, want, resp)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	s := New(1, 1)
+	var resp EvaluateResponse
+	status := call(t, s, "/evaluate", `{"position": "|1002000100443440022100001|01081723|", "depth": 3}`, &resp)
+	if status != http.StatusOK || !strings.HasPrefix(resp.Move, "X 17-12") || resp.Score < 999000 {
+		t.Fatalf("expected the winning climb, got %v %+v", status, resp)
+	}
+	if len(resp.PV) == 0 || resp.PV[0] != resp.Move || resp.Nodes == 0 {
+		t.Fatalf("unexpected answer %+v", resp)
+	}
+
+	// The server's time limit wins over the request's.
+	s.MaxMoveTime = 50 * time.Millisecond
+	start := time.Now()
+	resp = EvaluateResponse{}
+	call(t, s, "/evaluate", `{"position": "|0000000000000000000000000|07121117|w|", "movetime_ms": 60000}`, &resp)
+	if elapsed := time.Since(start); elapsed > 5*time.Second || resp.Move == "" {
+		t.Fatalf("expected a move within the server's limit, got %+v after %v", resp, elapsed)
+	}
+}
+
+func TestBusy(t *testing.T) {
+	s := New(1, 1)
+	s.QueueTimeout = 10 * time.Millisecond
+	// Take the only searcher, as a long search would.
+	searcher := <-s.searchers
+	var e errorResponse
+	if status := call(t, s, "/evaluate", `{"depth": 1}`, &e); status != http.StatusServiceUnavailable || e.Error != ErrBusy.Error() {
+		t.Fatalf("expected the server to be busy, got %v %+v", status, e)
+	}
+	s.searchers <- searcher
+	var resp EvaluateResponse
+	if status := call(t, s, "/evaluate", `{"position": "|0000000000000000000000000|07121117|w|", "depth": 1}`, &resp); status != http.StatusOK {
+		t.Fatalf("expected the search to run once the searcher is back, got %v", status)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	s := New(1, 1)
+	for _, c := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/moves", "", http.StatusMethodNotAllowed},
+		{"POST", "/moves", "{", http.StatusBadRequest},
+		{"POST", "/moves", `{"position": "|00|"}`, http.StatusBadRequest},
+		{"POST", "/moves", `{"colour": "red"}`, http.StatusBadRequest},
+		{"POST", "/moves", `{"position": "` + strings.Repeat("0", maxBody) + `"}`, http.StatusBadRequest},
+		{"POST", "/evaluate", `{"depth": -1}`, http.StatusBadRequest},
+		{"POST", "/nowhere", `{}`, http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(c.method, c.path, bytes.NewBufferString(c.body)))
+		if w.Code != c.status {
+			t.Fatalf("%v %v %.20q: expected %v, got %v %v", c.method, c.path, c.body, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Package websocket is a small implementation of the WebSocket protocol,
+// RFC 6455: enough for the game server to push JSON to browsers, and for
+// tests to connect to it, without depending on anything outside the
+// standard library.
+//
+// Messages may be text or binary, and are read whole, however the peer
+// fragments them. Pings are answered as they arrive, and a close from the
+// peer is answered and then reported as io.EOF.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// acceptGUID is mixed into the client's key to prove the server speaks
+// WebSocket.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultMaxMessageSize is the largest message a Conn reads, unless told
+// otherwise.
+const DefaultMaxMessageSize = 1 << 20
+
+// Opcodes of the frames that make up messages.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes.
+const (
+	CloseNormal         = 1000
+	CloseProtocolError  = 1002
+	CloseMessageTooBig  = 1009
+	closeNoStatusLocal  = 1005
+	maxControlFrameSize = 125
+)
+
+var (
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrProtocol     = errors.New("websocket: protocol error")
+	ErrTooBig       = errors.New("websocket: message too big")
+)
+
+// Conn is one end of a WebSocket connection. One goroutine may read while
+// others write.
+type Conn struct {
+	// MaxMessageSize is the largest message ReadMessage accepts.
+	MaxMessageSize int
+	// ReadTimeout, if not zero, is how long ReadMessage waits for each
+	// frame, pongs included, before giving up on the peer.
+	ReadTimeout time.Duration
+
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // Clients mask what they send, and servers mustn't.
+
+	wmu    sync.Mutex // Guards writes, and closed.
+	closed bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool) *Conn {
+	return &Conn{MaxMessageSize: DefaultMaxMessageSize, conn: conn, br: br, client: client}
+}
+
+// acceptKey is the Sec-WebSocket-Accept that answers key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerHas reports whether a comma separated header holds token, ignoring
+// case.
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade answers a client's opening handshake and takes over its
+// connection. If the request isn't a WebSocket handshake it answers 400
+// Bad Request and returns ErrBadHandshake.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerHas(r.Header, "Connection", "upgrade") ||
+		!headerHas(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "can't upgrade this connection", http.StatusInternalServerError)
+		return nil, ErrBadHandshake
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// Anything the client sent after the handshake is already buffered in
+	// rw, so reading carries on from there.
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return newConn(conn, rw.Reader, false), nil
+}
+
+// Dial opens a connection to a ws:// URL.
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket: can't dial %q, only ws:// is supported", rawURL)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host += ":80"
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Host:   u.Host,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %v", ErrBadHandshake, resp.Status)
+	}
+	return newConn(conn, br, true), nil
+}
+
+// frame is one frame read from the peer.
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame reads one frame, unmasking it, and checks it follows the rules
+// for the side it came from.
+func (c *Conn) readFrame(limit int) (frame, error) {
+	if c.ReadTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.ReadTimeout))
+	}
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return frame{}, err
+	}
+	f := frame{fin: head[0]&0x80 != 0, opcode: head[0] & 0x0F}
+	if head[0]&0x70 != 0 {
+		return f, ErrProtocol // No extensions were agreed.
+	}
+	masked := head[1]&0x80 != 0
+	if masked == c.client {
+		return f, ErrProtocol // Clients must mask, and servers mustn't.
+	}
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if f.opcode >= opClose && (size > maxControlFrameSize || !f.fin) {
+		return f, ErrProtocol
+	}
+	if size > uint64(limit) {
+		return f, ErrTooBig
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, size)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, nil
+}
+
+// ReadMessage reads the next text or binary message. When the peer closes
+// the connection it returns io.EOF.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		f, err := c.readFrame(c.MaxMessageSize - len(msg))
+		switch err {
+		case nil:
+		case ErrProtocol:
+			c.closeWith(CloseProtocolError)
+			return nil, err
+		case ErrTooBig:
+			c.closeWith(CloseMessageTooBig)
+			return nil, err
+		default:
+			return nil, err
+		}
+		switch f.opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, f.payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			// Echo the status, as the protocol asks.
+			code := closeNoStatusLocal
+			if len(f.payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(f.payload))
+			}
+			c.closeWith(code)
+			return nil, io.EOF
+		case opText, opBinary:
+			if started {
+				c.closeWith(CloseProtocolError)
+				return nil, ErrProtocol
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				c.closeWith(CloseProtocolError)
+				return nil, ErrProtocol
+			}
+		default:
+			c.closeWith(CloseProtocolError)
+			return nil, ErrProtocol
+		}
+		msg = append(msg, f.payload...)
+		if f.fin {
+			return msg, nil
+		}
+	}
+}
+
+// WriteMessage sends msg as one text message.
+func (c *Conn) WriteMessage(msg []byte) error {
+	return c.writeFrame(opText, msg)
+}
+
+// Ping sends a ping, which the peer should answer with a pong.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// writeFrame sends one final frame, masked if this is the client.
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+func (c *Conn) writeFrameLocked(opcode byte, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|opcode)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if !c.client {
+		buf = append(buf, payload...)
+		_, err := c.conn.Write(buf)
+		return err
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	buf = append(buf, mask[:]...)
+	for i, b := range payload {
+		buf = append(buf, b^mask[i%4])
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// closeWith sends a close frame with code, unless one has been sent, and
+// closes the connection.
+func (c *Conn) closeWith(code int) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	var payload []byte
+	if code != closeNoStatusLocal {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.writeFrameLocked(opClose, payload)
+	return c.conn.Close()
+}
+
+// Close sends a normal close frame and closes the connection, without
+// waiting for the peer to answer.
+func (c *Conn) Close() error {
+	return c.closeWith(CloseNormal)
+}
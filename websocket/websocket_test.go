@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echoServer echoes every message back, and hands its errors to errs.
+func echoServer(t *testing.T, errs chan<- error) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		c.MaxMessageSize = 1 << 17
+		for {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				errs <- err
+				return
+			}
+			c.WriteMessage(msg)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func wsURL(ts *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455, section 1.3.
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %v", got)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	errs := make(chan error, 1)
+	c, err := Dial(wsURL(echoServer(t, errs)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Short, 16 bit and 64 bit lengths.
+	for _, n := range []int{0, 5, 125, 126, 300, 70000} {
+		msg := strings.Repeat("x", n)
+		if err := c.WriteMessage([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.ReadMessage()
+		if err != nil || string(got) != msg {
+			t.Fatalf("expected %v bytes back, got %v, %v", n, len(got), err)
+		}
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMessage([]byte("after ping"))
+	if got, err := c.ReadMessage(); err != nil || string(got) != "after ping" {
+		t.Fatalf("expected the pong to be skipped, got %q, %v", got, err)
+	}
+	c.Close()
+	if err := <-errs; err != io.EOF {
+		t.Fatalf("expected the server to see the close as EOF, got %v", err)
+	}
+}
+
+// rawClient does the handshake by hand, so tests can send any frames.
+func rawClient(t *testing.T, ts *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected to switch protocols, got %v, %v", resp, err)
+	}
+	return conn, br
+}
+
+// masked builds a client frame with a fixed mask.
+func masked(head byte, payload string) []byte {
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{head, 0x80 | byte(len(payload))}, mask...)
+	for i := range payload {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	return frame
+}
+
+func TestFragments(t *testing.T) {
+	errs := make(chan error, 1)
+	conn, br := rawClient(t, echoServer(t, errs))
+	// A text frame, a ping in the middle, and two continuations.
+	var frames []byte
+	frames = append(frames, masked(opText, "Hel")...)
+	frames = append(frames, masked(0x80|opPing, "p")...)
+	frames = append(frames, masked(opContinuation, "lo, ")...)
+	frames = append(frames, masked(0x80|opContinuation, "world")...)
+	conn.Write(frames)
+
+	client := &Conn{MaxMessageSize: DefaultMaxMessageSize, conn: conn, br: br, client: true}
+	f, err := client.readFrame(100)
+	if err != nil || f.opcode != opPong || string(f.payload) != "p" {
+		t.Fatalf("expected a pong, got %+v, %v", f, err)
+	}
+	if msg, err := client.ReadMessage(); err != nil || string(msg) != "Hello, world" {
+		t.Fatalf("expected the message whole, got %q, %v", msg, err)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	unmasked := []byte{0x80 | opText, 2, 'h', 'i'}
+	for name, frame := range map[string][]byte{
+		"unmasked":        unmasked,
+		"continuation":    masked(0x80|opContinuation, "x"),
+		"unknown opcode":  masked(0x80|0x3, "x"),
+		"reserved bits":   masked(0xC0|opText, "x"),
+		"fragmented ping": masked(opPing, "x"),
+	} {
+		errs := make(chan error, 1)
+		conn, br := rawClient(t, echoServer(t, errs))
+		conn.Write(frame)
+		if err := <-errs; err != ErrProtocol {
+			t.Fatalf("%v: expected a protocol error, got %v", name, err)
+		}
+		// The server says why it is closing.
+		client := &Conn{conn: conn, br: br, client: true}
+		f, err := client.readFrame(100)
+		if err != nil || f.opcode != opClose || string(f.payload) != "\x03\xea" {
+			t.Fatalf("%v: expected a close with status 1002, got %+v, %v", name, f, err)
+		}
+	}
+}
+
+func TestTooBig(t *testing.T) {
+	errs := make(chan error, 1)
+	c, err := Dial(wsURL(echoServer(t, errs)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMessage(make([]byte, 1<<17+1))
+	if err := <-errs; err != ErrTooBig {
+		t.Fatalf("expected the message to be too big, got %v", err)
+	}
+	if _, err := c.ReadMessage(); err != io.EOF {
+		t.Fatalf("expected the server to close, got %v", err)
+	}
+}
+
+func TestBadHandshake(t *testing.T) {
+	ts := echoServer(t, make(chan error, 1))
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a plain GET to be refused, got %v", resp.Status)
+	}
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := Dial(wsURL(notFound)); !errors.Is(err, ErrBadHandshake) {
+		t.Fatalf("expected a bad handshake, got %v", err)
+	}
+	if _, err := Dial("wss://example.com/"); err == nil {
+		t.Fatalf("expected wss to be refused")
+	}
+}